@@ -0,0 +1,270 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package archive handles bundling the logs of a testnet into a tar.gz, so that they survive
+// the testnet being torn down
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+var nameRegex = regexp.MustCompile(`^[0-9]+\.tar\.gz$`)
+
+// Archive is a bundle of the logs of a testnet
+type Archive struct {
+	Name      string    `json:"name"`
+	TestNetID string    `json:"testnetId"`
+	Reason    string    `json:"reason"`
+	Size      int64     `json:"size"`
+	Created   time.Time `json:"created"`
+}
+
+// bundle is a tar.gz which can be safely written to from multiple goroutines
+type bundle struct {
+	mux sync.Mutex
+	gz  *gzip.Writer
+	tw  *tar.Writer
+	now time.Time
+}
+
+func (b *bundle) add(name string, data []byte) error {
+	return b.addStream(name, int64(len(data)), bytes.NewReader(data))
+}
+
+// addStream adds a file of the given size to the bundle, copying its contents from r
+func (b *bundle) addStream(name string, size int64, r io.Reader) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	err := b.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: b.now,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(b.tw, r, size)
+	return err
+}
+
+func (b *bundle) addJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.add(name, data)
+}
+
+func (b *bundle) close() error {
+	err := b.tw.Close()
+	if err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
+
+// getDirectory gets the directory where the archives of the given testnet are stored
+func getDirectory(testnetID string) string {
+	return filepath.Join(conf.DataDirectory, "archives", testnetID)
+}
+
+// getNodeLogFiles gets the log files of the node, by the name they are stored under in the archive
+func getNodeLogFiles(node db.Node) map[string]string {
+	out := map[string]string{"output.log": conf.DockerOutputFile}
+	for name, file := range registrar.GetAdditionalLogs(node.Protocol) {
+		out[name+".log"] = file
+	}
+	return out
+}
+
+// archiveFile streams a file out of a node into the bundle, returns an errNotRead if the file could not be read
+func archiveFile(b *bundle, client ssh.Client, node db.Node, name string, file string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(client.DockerCopyOut(node, file, pw))
+	}()
+	//Let the copy finish, whatever happens to the bundle
+	defer io.Copy(ioutil.Discard, pr)
+
+	tr := tar.NewReader(pr)
+	hdr, err := tr.Next()
+	if err != nil {
+		return errNotRead{err}
+	}
+	return b.addStream(name, hdr.Size, tr)
+}
+
+// errNotRead is the error for a file which could not be read from a node
+type errNotRead struct {
+	err error
+}
+
+func (err errNotRead) Error() string {
+	return err.err.Error()
+}
+
+// archiveNode adds the logs, details and command of a node to the bundle. Log files which cannot be
+// read are skipped, since a failed node may not have gotten around to creating them.
+func archiveNode(b *bundle, tn *testnet.TestNet, client ssh.Client, node db.Node) error {
+	dir := fmt.Sprintf("node%d", node.AbsoluteNum)
+	for name, file := range getNodeLogFiles(node) {
+		err := archiveFile(b, client, node, filepath.Join(dir, name), file)
+		if _, ok := err.(errNotRead); ok {
+			log.WithFields(log.Fields{"node": node.AbsoluteNum, "file": file, "error": err}).Warn("could not read a log file")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err := b.addJSON(filepath.Join(dir, "node.json"), node)
+	if err != nil {
+		return err
+	}
+	var cmd util.Command
+	if tn.BuildState.GetP(strconv.Itoa(node.AbsoluteNum), &cmd) {
+		return b.addJSON(filepath.Join(dir, "command.json"), cmd)
+	}
+	return nil
+}
+
+// Create bundles the output and additional logs of every node, along with the build state, into a
+// tar.gz stored under the data directory. The reason is recorded with the archive.
+func Create(tn *testnet.TestNet, reason string) (*Archive, error) {
+	dir := getDirectory(tn.TestNetID)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%d.tar.gz", now.UnixNano())
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	b := &bundle{gz: gz, tw: tar.NewWriter(gz), now: now}
+
+	nodes := map[int]db.Node{}
+	for _, node := range tn.Nodes {
+		nodes[node.AbsoluteNum] = node
+	}
+	err = helpers.AllNodeExecConDR(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		return archiveNode(b, tn, client, nodes[node.GetAbsoluteNumber()])
+	})
+	if err == nil {
+		err = b.add("buildstate.json", []byte(tn.BuildState.Marshal()))
+	}
+	if err == nil {
+		err = b.addJSON("details.json", tn.CombinedDetails)
+	}
+	if err == nil {
+		err = b.addJSON("archive.json", map[string]string{"testnetId": tn.TestNetID, "reason": reason})
+	}
+	if err == nil {
+		err = b.close()
+	}
+	if err != nil {
+		os.Remove(filepath.Join(dir, name))
+		return nil, util.LogError(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, name+".reason"), []byte(reason), 0644)
+
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "archive": name, "reason": reason}).Info("archived the logs")
+	return Get(tn.TestNetID, name)
+}
+
+// Get gets the archive of the testnet with the given name
+func Get(testnetID string, name string) (*Archive, error) {
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid archive name \"%s\"", name)
+	}
+	info, err := os.Stat(filepath.Join(getDirectory(testnetID), name))
+	if err != nil {
+		return nil, err
+	}
+	reason, _ := ioutil.ReadFile(filepath.Join(getDirectory(testnetID), name+".reason"))
+	return &Archive{
+		Name:      name,
+		TestNetID: testnetID,
+		Reason:    string(reason),
+		Size:      info.Size(),
+		Created:   info.ModTime(),
+	}, nil
+}
+
+// GetPath gets the location of the archive of the testnet with the given name
+func GetPath(testnetID string, name string) (string, error) {
+	_, err := Get(testnetID, name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(getDirectory(testnetID), name), nil
+}
+
+// List gets all of the archives of a testnet, oldest first
+func List(testnetID string) ([]Archive, error) {
+	files, err := ioutil.ReadDir(getDirectory(testnetID))
+	if os.IsNotExist(err) {
+		return []Archive{}, nil
+	}
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := []Archive{}
+	for _, file := range files {
+		if !nameRegex.MatchString(file.Name()) {
+			continue
+		}
+		archive, err := Get(testnetID, file.Name())
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, *archive)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
@@ -0,0 +1,97 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func writeTar(out io.Writer, name string, data string) error {
+	tw := tar.NewWriter(out)
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write([]byte(data))
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func TestArchiveFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0}
+	client.EXPECT().DockerCopyOut(node, "/output.log", gomock.Any()).DoAndReturn(
+		func(_ db.Node, _ string, out io.Writer) error {
+			return writeTar(out, "output.log", "hello world\n")
+		})
+	client.EXPECT().DockerCopyOut(node, "/missing.log", gomock.Any()).Return(
+		fmt.Errorf("no such container:path"))
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	b := &bundle{gz: gz, tw: tar.NewWriter(gz), now: time.Now()}
+
+	err := archiveFile(b, client, node, "node0/output.log", "/output.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = archiveFile(b, client, node, "node0/missing.log", "/missing.log")
+	if _, ok := err.(errNotRead); !ok {
+		t.Errorf("expected the missing file to not be read, got %v", err)
+	}
+	if err = b.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Name != "node0/output.log" || string(data) != "hello world\n" {
+		t.Errorf("unexpected file %s in the bundle: \"%s\"", hdr.Name, data)
+	}
+	if _, err = tr.Next(); err != io.EOF {
+		t.Errorf("expected only one file in the bundle, got %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package chaos handles injecting randomized, but reproducible, faults into a testnet
+package chaos
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	netconf "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"sync"
+	"time"
+)
+
+const (
+	// Running is the state of a plan which is still injecting faults
+	Running = "running"
+	// Finished is the state of a plan which has run for its whole duration
+	Finished = "finished"
+	// Stopped is the state of a plan which was stopped before it finished
+	Stopped = "stopped"
+)
+
+// maxHistory is the number of the most recent actions kept in the status of a plan
+const maxHistory = 100
+
+// Status is the progress of a chaos plan on a testnet
+type Status struct {
+	TestnetID string    `json:"testnetId"`
+	Plan      Plan      `json:"plan"`
+	State     string    `json:"state"`
+	Started   time.Time `json:"started"`
+	// Executed is the number of actions which have been taken
+	Executed int `json:"executed"`
+	// Failed is the number of actions which failed
+	Failed int `json:"failed"`
+	// History holds the most recent actions
+	History []Action `json:"history"`
+}
+
+type runner struct {
+	status   Status
+	planner  *planner
+	exec     func(Action) error
+	stop     chan struct{}
+	stopOnce sync.Once
+	mux      sync.RWMutex
+}
+
+var (
+	runners   = map[string]*runner{}
+	runnerMux = sync.Mutex{}
+)
+
+func (r *runner) getStatus() Status {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	out := r.status
+	out.History = append([]Action{}, r.status.History...)
+	return out
+}
+
+func (r *runner) execute(action Action) {
+	err := r.exec(action)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.status.Executed++
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": r.status.TestnetID, "action": action, "error": err}).Error("chaos action failed")
+		r.status.Failed++
+	}
+	r.status.History = append(r.status.History, action)
+	if len(r.status.History) > maxHistory {
+		r.status.History = r.status.History[len(r.status.History)-maxHistory:]
+	}
+}
+
+func (r *runner) finish(state string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.status.State = state
+}
+
+func (r *runner) run() {
+	start := r.getStatus().Started
+	for {
+		action, ok := r.planner.Next()
+		if !ok {
+			r.finish(Finished)
+			return
+		}
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(action.At) * time.Second)))
+		select {
+		case <-r.stop:
+			timer.Stop()
+			if action.Revert {
+				r.execute(action)
+			} else {
+				r.planner.Skip(action)
+			}
+			//Leave the network as it was found, apart from the killed nodes
+			for _, revert := range r.planner.Pending() {
+				r.execute(revert)
+			}
+			r.finish(Stopped)
+			return
+		case <-timer.C:
+		}
+		if len(action.Nodes) == 0 {
+			log.WithFields(log.Fields{"testnet": r.status.TestnetID, "fault": action.Fault}).Debug("no nodes available for the fault")
+			continue
+		}
+		log.WithFields(log.Fields{"testnet": r.status.TestnetID, "action": action}).Info("executing chaos action")
+		r.execute(action)
+	}
+}
+
+func start(testnetID string, plan Plan, nodes []int, exec func(Action) error) (Status, error) {
+	err := plan.Validate()
+	if err != nil {
+		return Status{}, err
+	}
+	if plan.Seed == 0 {
+		plan.Seed = time.Now().UnixNano()
+	}
+	runnerMux.Lock()
+	defer runnerMux.Unlock()
+	if prev, ok := runners[testnetID]; ok && prev.getStatus().State == Running {
+		return Status{}, fmt.Errorf("chaos is already running on testnet %s", testnetID)
+	}
+	r := &runner{
+		status: Status{
+			TestnetID: testnetID,
+			Plan:      plan,
+			State:     Running,
+			Started:   time.Now(),
+			History:   []Action{},
+		},
+		planner: newPlanner(plan, nodes),
+		exec:    exec,
+		stop:    make(chan struct{}),
+	}
+	runners[testnetID] = r
+	go r.run()
+	return r.getStatus(), nil
+}
+
+// executor takes the actions of a plan on a testnet, recording each of them in the event journal
+type executor struct {
+	tn    *testnet.TestNet
+	plan  Plan
+	nodes []db.Node
+	kid   string
+	// previous holds the network conditions of the nodes under a netem fault from before the fault,
+	// nil for the nodes which did not have any
+	previous map[int]*netconf.Netconf
+}
+
+// injectNetem applies the network conditions of a netem fault to the nodes, remembering the conditions
+// they had before so that they can be restored. Nodes with per link conditions are left alone, as
+// applying netem to them would wipe out their links.
+func (e executor) injectNetem(nconf netconf.Netconf, nodes []db.Node) error {
+	intent := netconf.GetIntent(e.tn.TestNetID)
+	linked := netconf.GetLinkedNodes(e.tn.TestNetID)
+	skipped := []int{}
+	for _, node := range nodes {
+		if linked[node.AbsoluteNum] {
+			skipped = append(skipped, node.AbsoluteNum)
+			continue
+		}
+		if prev, ok := intent[node.AbsoluteNum]; ok {
+			e.previous[node.AbsoluteNum] = &prev
+		} else {
+			e.previous[node.AbsoluteNum] = nil
+		}
+		if err := netconf.ApplyToAll(nconf, []db.Node{node}); err != nil {
+			return err
+		}
+	}
+	if len(skipped) > 0 {
+		return fmt.Errorf("nodes %v have per link network conditions, they were left alone", skipped)
+	}
+	return nil
+}
+
+// revertNetem puts back the network conditions the nodes had before a netem fault
+func (e executor) revertNetem(nodes []db.Node) error {
+	for _, node := range nodes {
+		prev, ok := e.previous[node.AbsoluteNum]
+		if !ok {
+			continue
+		}
+		delete(e.previous, node.AbsoluteNum)
+		var err error
+		if prev == nil {
+			err = netconf.RemoveAll([]db.Node{node})
+		} else {
+			err = netconf.ApplyToAll(*prev, []db.Node{node})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e executor) getNodes(nums []int) ([]db.Node, error) {
+	out := []db.Node{}
+	for _, num := range nums {
+		node, err := db.GetNodeByAbsNum(e.nodes, num)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func (e executor) exec(action Action) error {
+	fault := e.plan.Faults[action.Fault]
+	nodes, err := e.getNodes(action.Nodes)
+	if err != nil {
+		return err
+	}
+	switch {
+	case action.Type == FaultKill:
+		for _, node := range nodes {
+			client, err := status.GetClient(node.Server)
+			if err != nil {
+				return err
+			}
+			if err = docker.KillNode(client, node.LocalID); err != nil {
+				return err
+			}
+		}
+	case action.Type == FaultSignal:
+		for _, node := range nodes {
+			if err = helpers.SignalNode(e.tn, node, fault.Signal); err != nil {
+				return err
+			}
+		}
+	case action.Type == FaultNetem && action.Revert:
+		err = e.revertNetem(nodes)
+	case action.Type == FaultNetem:
+		err = e.injectNetem(*fault.Netconf, nodes)
+	case action.Type == FaultOutage:
+		for i := 0; i+1 < len(nodes); i += 2 {
+			if action.Revert {
+				err = netconf.RemoveOutage(nodes[i], nodes[i+1])
+			} else {
+				err = netconf.MakeOutage(nodes[i], nodes[i+1])
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case action.Type == FaultPartition:
+		side1, side2, err := db.DivideNodesByAbsMatch(e.nodes, action.Nodes)
+		if err != nil {
+			return err
+		}
+		if action.Revert {
+			err = netconf.RemovePartitionOutage(side1, side2)
+		} else {
+			err = netconf.CreatePartitionOutage(side1, side2)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	name := "chaos " + action.Type
+	if action.Revert {
+		name += " revert"
+	}
+	_, err = db.InsertEvent(db.Event{
+		TestNetID: e.tn.TestNetID,
+		Kid:       e.kid,
+		Action:    name,
+		Nodes:     action.Nodes,
+		Details:   action,
+	})
+	return err
+}
+
+// Start starts injecting the faults of the plan into the testnet in the background. Only one plan can run
+// on a testnet at a time. kid is recorded as the one responsible for the faults in the event journal.
+func Start(testnetID string, plan Plan, kid string) (Status, error) {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return Status{}, err
+	}
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return Status{}, err
+	}
+	nums := []int{}
+	for _, node := range nodes {
+		nums = append(nums, node.AbsoluteNum)
+	}
+	//Validate ahead of time, so that the executor has the defaults filled in
+	err = plan.Validate()
+	if err != nil {
+		return Status{}, err
+	}
+	exec := executor{tn: tn, plan: plan, nodes: nodes, kid: kid, previous: map[int]*netconf.Netconf{}}
+	return start(testnetID, plan, nums, exec.exec)
+}
+
+// GetStatus gets the status of the latest plan on the given testnet
+func GetStatus(testnetID string) (Status, error) {
+	runnerMux.Lock()
+	defer runnerMux.Unlock()
+	r, ok := runners[testnetID]
+	if !ok {
+		return Status{}, fmt.Errorf("chaos has not been run on testnet %s", testnetID)
+	}
+	return r.getStatus(), nil
+}
+
+// Stop stops the plan running on the given testnet, reverting the faults which are still in effect.
+// Killed nodes are not brought back.
+func Stop(testnetID string) error {
+	runnerMux.Lock()
+	defer runnerMux.Unlock()
+	r, ok := runners[testnetID]
+	if !ok || r.getStatus().State != Running {
+		return fmt.Errorf("chaos is not running on testnet %s", testnetID)
+	}
+	r.stopOnce.Do(func() { close(r.stop) })
+	return nil
+}
@@ -0,0 +1,366 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	netconf "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/util"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	// FaultKill removes the containers of the targeted nodes, it cannot be reverted
+	FaultKill = "kill"
+	// FaultSignal sends a signal to the main process of the targeted nodes
+	FaultSignal = "signal"
+	// FaultNetem applies network conditions to the targeted nodes
+	FaultNetem = "netem"
+	// FaultOutage cuts the links between random pairs of the targeted nodes
+	FaultOutage = "outage"
+	// FaultPartition partitions the targeted nodes from the rest of the network
+	FaultPartition = "partition"
+)
+
+// Fault is a kind of fault which is injected periodically
+type Fault struct {
+	// Type is the kind of fault, one of kill, signal, netem, outage or partition
+	Type string `json:"type"`
+	// Every is the number of seconds between each injection of the fault
+	Every int `json:"every"`
+	// Fraction is the fraction of the nodes targeted by each injection
+	Fraction float64 `json:"fraction,omitempty"`
+	// Count is the number of nodes targeted by each injection, overrides Fraction. For outages, this
+	// is the number of links cut.
+	Count int `json:"count,omitempty"`
+	// Duration is the number of seconds until the fault is reverted. Required for netem, outage and partition.
+	Duration int `json:"duration,omitempty"`
+	// Signal is the signal sent by a signal fault, defaults to KILL. Nodes sent a signal which stops or
+	// terminates a process by default count against the blast radius for the rest of the plan.
+	Signal string `json:"signal,omitempty"`
+	// Netconf is the network conditions applied by a netem fault
+	Netconf *netconf.Netconf `json:"netconf,omitempty"`
+}
+
+// Plan describes the faults to randomly inject into a testnet. The same seed on the same testnet
+// always produces the same sequence of actions.
+type Plan struct {
+	// Seed is the seed of the random choices, a random seed is chosen when not given
+	Seed int64 `json:"seed"`
+	// Duration is the number of seconds to inject faults for, 0 to inject them until stopped
+	Duration int `json:"duration"`
+	// MaxNodes is the largest number of nodes which can be under a fault at once
+	MaxNodes int `json:"maxNodes,omitempty"`
+	// MaxFraction is the largest fraction of the nodes which can be under a fault at once
+	MaxFraction float64 `json:"maxFraction,omitempty"`
+	// Protected are the absolute numbers of the nodes which are never targeted
+	Protected []int `json:"protected,omitempty"`
+	// Faults are the kinds of faults to inject
+	Faults []Fault `json:"faults"`
+}
+
+// Action is a single injection or reversion of a fault
+type Action struct {
+	// At is the number of seconds after the start of the plan the action happens
+	At int `json:"at"`
+	// Fault is the index of the fault in the plan
+	Fault int `json:"fault"`
+	// Type is the kind of fault
+	Type string `json:"type"`
+	// Revert is true if this action reverts an earlier injection
+	Revert bool `json:"revert"`
+	// Nodes are the absolute numbers of the targeted nodes
+	Nodes []int `json:"nodes"`
+	// Links are the pairs of nodes whose connections are cut by an outage
+	Links [][2]int `json:"links,omitempty"`
+}
+
+// Validate checks that the plan is valid, and fills in the defaults
+func (plan *Plan) Validate() error {
+	if len(plan.Faults) == 0 {
+		return fmt.Errorf("plan does not have any faults")
+	}
+	if plan.Duration < 0 {
+		return fmt.Errorf("duration cannot be negative")
+	}
+	if plan.MaxNodes < 0 {
+		return fmt.Errorf("maxNodes cannot be negative")
+	}
+	if plan.MaxFraction < 0 || plan.MaxFraction > 1 {
+		return fmt.Errorf("maxFraction must be between 0 and 1")
+	}
+	for i := range plan.Faults {
+		fault := &plan.Faults[i]
+		if fault.Every <= 0 {
+			return fmt.Errorf("fault %d: every must be greater than zero", i)
+		}
+		if fault.Count < 0 || fault.Fraction < 0 || fault.Fraction > 1 {
+			return fmt.Errorf("fault %d: count must be positive and fraction must be between 0 and 1", i)
+		}
+		if fault.Count == 0 && fault.Fraction == 0 {
+			fault.Count = 1
+		}
+		if fault.Duration < 0 {
+			return fmt.Errorf("fault %d: duration cannot be negative", i)
+		}
+		switch fault.Type {
+		case FaultKill:
+		case FaultSignal:
+			if len(fault.Signal) == 0 {
+				fault.Signal = "KILL"
+			}
+			if err := util.ValidateCommandLine(fault.Signal); err != nil {
+				return fmt.Errorf("fault %d: invalid signal \"%s\"", i, fault.Signal)
+			}
+		case FaultNetem:
+			if fault.Netconf == nil {
+				return fmt.Errorf("fault %d: netem faults need network conditions", i)
+			}
+			if err := fault.Netconf.Validate(); err != nil {
+				return fmt.Errorf("fault %d: %s", i, err.Error())
+			}
+			fallthrough
+		case FaultOutage, FaultPartition:
+			if fault.Duration == 0 {
+				return fmt.Errorf("fault %d: %s faults need a duration", i, fault.Type)
+			}
+		default:
+			return fmt.Errorf("fault %d: unknown fault type \"%s\"", i, fault.Type)
+		}
+	}
+	return nil
+}
+
+// harmlessSignals are the signals which neither stop nor terminate a process by default
+var harmlessSignals = map[string]bool{"CONT": true, "CHLD": true, "URG": true, "WINCH": true}
+
+// isFatal checks if the given signal stops or terminates a process which doesn't handle it
+func isFatal(signal string) bool {
+	return !harmlessSignals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]
+}
+
+// planner generates the actions of a plan in order. All of the random choices are made from a
+// single source seeded by the plan, in the order of the actions, which makes the actions reproducible.
+type planner struct {
+	plan      Plan
+	rng       *rand.Rand
+	nodes     []int
+	protected map[int]bool
+	limit     int
+
+	next    []int
+	pending []Action
+	faulted map[int]bool
+	killed  map[int]bool
+}
+
+func newPlanner(plan Plan, nodes []int) *planner {
+	p := &planner{
+		plan:      plan,
+		rng:       rand.New(rand.NewSource(plan.Seed)),
+		nodes:     append([]int{}, nodes...),
+		protected: map[int]bool{},
+		next:      make([]int, len(plan.Faults)),
+		pending:   []Action{},
+		faulted:   map[int]bool{},
+		killed:    map[int]bool{},
+	}
+	sort.Ints(p.nodes)
+	for _, node := range plan.Protected {
+		p.protected[node] = true
+	}
+	for i, fault := range plan.Faults {
+		p.next[i] = fault.Every
+	}
+
+	//By default, at most a third of the nodes can be faulted, the most byzantine fault tolerant
+	//protocols can handle
+	p.limit = len(p.nodes) / 3
+	if plan.MaxNodes > 0 || plan.MaxFraction > 0 {
+		p.limit = len(p.nodes)
+		if plan.MaxNodes > 0 && plan.MaxNodes < p.limit {
+			p.limit = plan.MaxNodes
+		}
+		if byFraction := int(plan.MaxFraction * float64(len(p.nodes))); plan.MaxFraction > 0 && byFraction < p.limit {
+			p.limit = byFraction
+		}
+	}
+	if p.limit == 0 {
+		p.limit = 1
+	}
+	return p
+}
+
+// eligible gets the nodes which can currently be targeted
+func (p *planner) eligible() []int {
+	out := []int{}
+	for _, node := range p.nodes {
+		if !p.protected[node] && !p.faulted[node] && !p.killed[node] {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// budget gets the number of nodes which can still be faulted without going over the limit
+func (p *planner) budget() int {
+	return p.limit - len(p.faulted) - len(p.killed)
+}
+
+// choose randomly picks up to count of the eligible nodes, within the budget
+func (p *planner) choose(count int) []int {
+	eligible := p.eligible()
+	perm := p.rng.Perm(len(eligible))
+	if budget := p.budget(); count > budget {
+		count = budget
+	}
+	if count > len(eligible) {
+		count = len(eligible)
+	}
+	if count <= 0 {
+		return []int{}
+	}
+	out := []int{}
+	for _, i := range perm[:count] {
+		out = append(out, eligible[i])
+	}
+	return out
+}
+
+// count gets the number of nodes targeted by an injection of the fault
+func (p *planner) count(fault Fault) int {
+	if fault.Count > 0 {
+		return fault.Count
+	}
+	live := 0
+	for _, node := range p.nodes {
+		if !p.protected[node] && !p.killed[node] {
+			live++
+		}
+	}
+	return int(math.Ceil(fault.Fraction * float64(live)))
+}
+
+// inject creates the action which injects the given fault, the action has no nodes if no nodes could be targeted
+func (p *planner) inject(at int, index int) Action {
+	fault := p.plan.Faults[index]
+	action := Action{At: at, Fault: index, Type: fault.Type, Nodes: []int{}}
+	switch fault.Type {
+	case FaultOutage:
+		chosen := p.choose(2 * p.count(fault))
+		for i := 0; i+1 < len(chosen); i += 2 {
+			action.Links = append(action.Links, [2]int{chosen[i], chosen[i+1]})
+			action.Nodes = append(action.Nodes, chosen[i], chosen[i+1])
+		}
+	case FaultPartition:
+		count := p.count(fault)
+		if live := len(p.nodes) - len(p.killed); count >= live {
+			count = live - 1
+		}
+		action.Nodes = p.choose(count)
+	default:
+		action.Nodes = p.choose(p.count(fault))
+	}
+	sort.Ints(action.Nodes)
+	if len(action.Nodes) == 0 {
+		return action
+	}
+
+	switch fault.Type {
+	case FaultKill:
+		for _, node := range action.Nodes {
+			p.killed[node] = true
+		}
+	case FaultSignal:
+		if !isFatal(fault.Signal) {
+			break
+		}
+		for _, node := range action.Nodes {
+			p.killed[node] = true
+		}
+	default:
+		for _, node := range action.Nodes {
+			p.faulted[node] = true
+		}
+		revert := action
+		revert.At = at + fault.Duration
+		revert.Revert = true
+		p.pending = append(p.pending, revert)
+		sort.SliceStable(p.pending, func(i, j int) bool { return p.pending[i].At < p.pending[j].At })
+	}
+	return action
+}
+
+// Next gets the next action of the plan, false if there are no more actions. Reverts which are pending
+// once the plan has finished are still returned. An injection which could not target any nodes, due to
+// the blast radius limit, is returned without any nodes.
+func (p *planner) Next() (Action, bool) {
+	injectAt := -1
+	index := -1
+	for i, at := range p.next {
+		if p.plan.Duration > 0 && at > p.plan.Duration {
+			continue
+		}
+		if injectAt == -1 || at < injectAt {
+			injectAt = at
+			index = i
+		}
+	}
+	if len(p.pending) > 0 && (injectAt == -1 || p.pending[0].At <= injectAt) {
+		revert := p.pending[0]
+		p.pending = p.pending[1:]
+		for _, node := range revert.Nodes {
+			delete(p.faulted, node)
+		}
+		return revert, true
+	}
+	if index == -1 {
+		return Action{}, false
+	}
+	p.next[index] += p.plan.Faults[index].Every
+	return p.inject(injectAt, index), true
+}
+
+// Skip forgets an injection returned by Next which was never executed, along with its revert
+func (p *planner) Skip(action Action) {
+	if action.Revert {
+		return
+	}
+	for i, revert := range p.pending {
+		if revert.Fault == action.Fault && revert.At == action.At+p.plan.Faults[action.Fault].Duration {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			break
+		}
+	}
+	for _, node := range action.Nodes {
+		delete(p.faulted, node)
+		delete(p.killed, node)
+	}
+}
+
+// Pending gets the reverts which have not happened yet, removing them from the planner
+func (p *planner) Pending() []Action {
+	out := p.pending
+	p.pending = []Action{}
+	p.faulted = map[int]bool{}
+	return out
+}
@@ -0,0 +1,227 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package chaos
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	netconf "github.com/whiteblock/genesis/net"
+)
+
+func getActions(plan Plan, nodes []int) []Action {
+	p := newPlanner(plan, nodes)
+	out := []Action{}
+	for {
+		action, ok := p.Next()
+		if !ok {
+			return out
+		}
+		out = append(out, action)
+	}
+}
+
+func testPlan(seed int64) Plan {
+	return Plan{
+		Seed:     seed,
+		Duration: 600,
+		MaxNodes: 4,
+		Faults: []Fault{
+			{Type: FaultNetem, Every: 60, Fraction: 0.2, Duration: 90, Netconf: &netconf.Netconf{Delay: 200000}},
+			{Type: FaultOutage, Every: 45, Count: 1, Duration: 30},
+			{Type: FaultSignal, Every: 120, Count: 1},
+		},
+	}
+}
+
+func TestPlanner_Deterministic(t *testing.T) {
+	nodes := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	plan := testPlan(42)
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	first := getActions(plan, nodes)
+	second := getActions(plan, nodes)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed produced different actions")
+	}
+	other := getActions(testPlan(43), nodes)
+	if reflect.DeepEqual(first, other) {
+		t.Error("different seeds produced the same actions")
+	}
+}
+
+func TestPlanner_BlastRadius(t *testing.T) {
+	nodes := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	plan := testPlan(7)
+	plan.Protected = []int{0, 1}
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	faulted := map[int]bool{}
+	killed := map[int]bool{}
+	last := -1
+	reverts := 0
+	injections := 0
+	for _, action := range getActions(plan, nodes) {
+		if action.At < last {
+			t.Fatalf("actions are out of order, %d came after %d", action.At, last)
+		}
+		last = action.At
+		for _, node := range action.Nodes {
+			if node == 0 || node == 1 {
+				t.Errorf("protected node %d was targeted", node)
+			}
+			if killed[node] {
+				t.Errorf("killed node %d was targeted", node)
+			}
+		}
+		switch {
+		case action.Type == FaultSignal:
+			for _, node := range action.Nodes {
+				killed[node] = true
+			}
+		case action.Revert:
+			reverts++
+			for _, node := range action.Nodes {
+				delete(faulted, node)
+			}
+		default:
+			if len(action.Nodes) > 0 {
+				injections++
+			}
+			for _, node := range action.Nodes {
+				if faulted[node] {
+					t.Errorf("node %d was faulted twice at once", node)
+				}
+				faulted[node] = true
+			}
+		}
+		if len(faulted)+len(killed) > plan.MaxNodes {
+			t.Errorf("%d nodes are faulted at %d, more than the limit of %d", len(faulted)+len(killed),
+				action.At, plan.MaxNodes)
+		}
+	}
+	if reverts != injections {
+		t.Errorf("expected every injection to be reverted, got %d injections and %d reverts", injections, reverts)
+	}
+	if len(faulted) != 0 {
+		t.Errorf("nodes %v are still faulted at the end of the plan", faulted)
+	}
+}
+
+func TestPlanner_HarmlessSignals(t *testing.T) {
+	nodes := []int{0, 1, 2, 3, 4, 5}
+	for _, signal := range []string{"KILL", "STOP", "SIGTERM", "CONT", "SIGWINCH"} {
+		plan := Plan{Seed: 3, Duration: 100, MaxNodes: 2, Faults: []Fault{{Type: FaultSignal, Every: 10, Signal: signal}}}
+		if err := plan.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		targeted := map[int]bool{}
+		for _, action := range getActions(plan, nodes) {
+			for _, node := range action.Nodes {
+				targeted[node] = true
+			}
+		}
+		if isFatal(signal) && len(targeted) > plan.MaxNodes {
+			t.Errorf("%s: expected at most %d nodes to be signaled, got %v", signal, plan.MaxNodes, targeted)
+		}
+		if !isFatal(signal) && len(targeted) <= plan.MaxNodes {
+			t.Errorf("%s: expected the harmless signal to not count against the limit, got %v", signal, targeted)
+		}
+	}
+}
+
+func TestPlanner_Skip(t *testing.T) {
+	nodes := []int{0, 1, 2, 3}
+	plan := Plan{Seed: 5, Duration: 100, MaxNodes: 4, Faults: []Fault{{Type: FaultOutage, Every: 50, Count: 1, Duration: 30}}}
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	p := newPlanner(plan, nodes)
+	action, ok := p.Next()
+	if !ok || action.Revert || len(action.Nodes) != 2 {
+		t.Fatalf("expected an outage to be injected, got %+v", action)
+	}
+	p.Skip(action)
+	if pending := p.Pending(); len(pending) != 0 {
+		t.Errorf("expected the revert of the skipped injection to be dropped, got %+v", pending)
+	}
+	if len(p.eligible()) != len(nodes) {
+		t.Errorf("expected the nodes of the skipped injection to be eligible again, got %v", p.eligible())
+	}
+}
+
+func TestStop_BeforeInjection(t *testing.T) {
+	plan := Plan{Seed: 5, Duration: 2000, MaxNodes: 4, Faults: []Fault{{Type: FaultOutage, Every: 1000, Count: 1, Duration: 30}}}
+	mux := sync.Mutex{}
+	executed := []Action{}
+	_, err := start("chaos-stop-test", plan, []int{0, 1, 2, 3}, func(action Action) error {
+		mux.Lock()
+		defer mux.Unlock()
+		executed = append(executed, action)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Let the runner choose the first injection and start waiting on it
+	time.Sleep(50 * time.Millisecond)
+	if err = Stop("chaos-stop-test"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		status, err := GetStatus("chaos-stop-test")
+		if err == nil && status.State == Stopped {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status, _ := GetStatus("chaos-stop-test"); status.State != Stopped {
+		t.Fatalf("expected the plan to be stopped, got %s", status.State)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(executed) != 0 {
+		t.Errorf("expected nothing to be reverted for an injection which never happened, got %+v", executed)
+	}
+}
+
+func TestPlan_Validate(t *testing.T) {
+	var test = []struct {
+		plan  Plan
+		valid bool
+	}{
+		{plan: Plan{Faults: []Fault{{Type: FaultKill, Every: 10}}}, valid: true},
+		{plan: Plan{Faults: []Fault{{Type: FaultSignal, Every: 10, Signal: "STOP; rm"}}}, valid: false},
+		{plan: Plan{Faults: []Fault{{Type: FaultOutage, Every: 10}}}, valid: false},
+		{plan: Plan{Faults: []Fault{{Type: FaultNetem, Every: 10, Duration: 5}}}, valid: false},
+		{plan: Plan{Faults: []Fault{{Type: "flood", Every: 10}}}, valid: false},
+		{plan: Plan{Faults: []Fault{{Type: FaultKill}}}, valid: false},
+		{plan: Plan{}, valid: false},
+	}
+	for i, tt := range test {
+		err := tt.plan.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%d: expected valid to be %v, got error %v", i, tt.valid, err)
+		}
+	}
+}
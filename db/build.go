@@ -97,6 +97,35 @@ func (dd DeploymentDetails) GetKid() string {
 	return dd.kid
 }
 
+//GetImage gets the image of the node with the given absolute number, which defaults to the first image
+func (dd DeploymentDetails) GetImage(node int) string {
+	if len(dd.Images) > node {
+		return dd.Images[node]
+	}
+	return dd.Images[0]
+}
+
+//GetResources gets the resources of the node with the given absolute number, which default to the
+//resources of the first node, or no limits if there are no resources given
+func (dd DeploymentDetails) GetResources(node int) util.Resources {
+	if len(dd.Resources) > node {
+		return dd.Resources[node]
+	}
+	if len(dd.Resources) == 0 {
+		return util.Resources{Cpus: "", Memory: ""}
+	}
+	return dd.Resources[0]
+}
+
+//GetEnvironment gets the environment variables of the node with the given absolute number, nil if
+//there are none
+func (dd DeploymentDetails) GetEnvironment(node int) map[string]string {
+	if len(dd.Environments) > node {
+		return dd.Environments[node]
+	}
+	return nil
+}
+
 //QueryBuilds fetches DeploymentDetails based on the given SQL select query
 func QueryBuilds(query string) ([]DeploymentDetails, error) {
 	rows, err := db.Query(query)
@@ -35,6 +35,8 @@ const (
 	NodesTable = "nodes"
 	//BuildsTable contains name of the builds table
 	BuildsTable = "builds"
+	//EventsTable contains name of the events table
+	EventsTable = "events"
 )
 
 var (
@@ -77,14 +79,16 @@ func dbInit(dataLoc string) error {
 		return util.LogError(err)
 	}
 	log.Debug("initializing tables")
-	serverSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s);",
+	serverSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s);",
 		ServerTable,
 		"id INTEGER PRIMARY KEY AUTOINCREMENT",
 		"server_id INTEGER",
 		"addr TEXT NOT NULL",
 		"nodes INTEGER DEFAULT 0",
 		"max INTEGER",
-		"name TEXT")
+		"name TEXT",
+		"cpus INTEGER DEFAULT 0",
+		"memory INTEGER DEFAULT 0")
 
 	nodesSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s);",
 		NodesTable,
@@ -114,6 +118,16 @@ func dbInit(dataLoc string) error {
 		"extras TEXT",
 		"kid TEXT")
 
+	eventsSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s);",
+		EventsTable,
+		"id INTEGER PRIMARY KEY AUTOINCREMENT",
+		"testnet TEXT NOT NULL",
+		"kid TEXT",
+		"action TEXT NOT NULL",
+		"nodes TEXT",
+		"details TEXT",
+		"time TEXT NOT NULL")
+
 	versionSchema := fmt.Sprintf("CREATE TABLE meta (%s,%s);",
 		"key TEXT",
 		"value TEXT",
@@ -132,6 +146,10 @@ func dbInit(dataLoc string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	_, err = db.Exec(eventsSchema)
+	if err != nil {
+		return util.LogError(err)
+	}
 	_, err = db.Exec(versionSchema)
 	if err != nil {
 		return util.LogError(err)
@@ -0,0 +1,133 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
+	"github.com/whiteblock/genesis/util"
+	"time"
+)
+
+// Event is an entry in the journal of actions taken on a testnet
+type Event struct {
+	// ID is the position of the event in the journal
+	ID int `json:"id"`
+
+	// TestNetID is the id of the testnet the action was taken on
+	TestNetID string `json:"testnetId"`
+
+	// Kid is the kid of the jwt of whoever took the action
+	Kid string `json:"kid"`
+
+	// Action is what was done, such as "kill" or "outage"
+	Action string `json:"action"`
+
+	// Nodes are the absolute numbers of the nodes which were targeted, empty if the action
+	// targeted the whole testnet
+	Nodes []int `json:"nodes"`
+
+	// Details holds the parameters of the action
+	Details interface{} `json:"details,omitempty"`
+
+	// Time is when the action was taken
+	Time time.Time `json:"time"`
+}
+
+// InsertEvent appends an event to the journal. The journal is append only, so events
+// cannot be updated or removed.
+func InsertEvent(event Event) (int, error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Nodes == nil {
+		event.Nodes = []int{}
+	}
+	nodes, err := json.Marshal(event.Nodes)
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (testnet,kid,action,nodes,details,time) VALUES (?,?,?,?,?,?)",
+		EventsTable))
+	if err != nil {
+		tx.Rollback()
+		return -1, util.LogError(err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(event.TestNetID, event.Kid, event.Action, string(nodes), string(details),
+		event.Time.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		tx.Rollback()
+		return -1, util.LogError(err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), util.LogError(err)
+}
+
+// GetEventsByTestNet gets the journal of a testnet, in the order the events happened
+func GetEventsByTestNet(testnetID string) ([]Event, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT id,testnet,kid,action,nodes,details,time FROM %s WHERE testnet = ? ORDER BY id ASC",
+		EventsTable), testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+
+	out := []Event{}
+	for rows.Next() {
+		var event Event
+		var nodes string
+		var details string
+		var timestamp string
+		err = rows.Scan(&event.ID, &event.TestNetID, &event.Kid, &event.Action, &nodes, &details, &timestamp)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		err = json.Unmarshal([]byte(nodes), &event.Nodes)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		err = json.Unmarshal([]byte(details), &event.Details)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		event.Time, err = time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, event)
+	}
+	return out, util.LogError(rows.Err())
+}
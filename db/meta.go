@@ -24,13 +24,20 @@ import (
 	"github.com/whiteblock/genesis/util"
 )
 
-//SetMeta stores a key value pair in the sql-lite database as json
+//SetMeta stores a key value pair in the sql-lite database as json, replacing
+//the previous value of key
 func SetMeta(key string, value interface{}) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return util.LogError(err)
 	}
 
+	_, err = tx.Exec("DELETE FROM meta WHERE key = ?", key)
+	if err != nil {
+		tx.Rollback()
+		return util.LogError(err)
+	}
+
 	stmt, err := tx.Prepare("INSERT INTO meta (key,value) VALUES (?,?)")
 
 	if err != nil {
@@ -180,6 +180,26 @@ func GetNodeByLocalID(nodes []Node, localID int) (Node, error) {
 	return Node{}, fmt.Errorf("node %d not found", localID)
 }
 
+// GetNodeByServerAndLocalID looks up a node by the server it is on and its localID
+func GetNodeByServerAndLocalID(nodes []Node, serverID int, localID int) (Node, error) {
+	for _, node := range nodes {
+		if node.Server == serverID && node.LocalID == localID {
+			return node, nil
+		}
+	}
+	return Node{}, fmt.Errorf("node %d not found on server %d", localID, serverID)
+}
+
+// GetNodeByIP looks up a node by its ip address
+func GetNodeByIP(nodes []Node, ip string) (Node, error) {
+	for _, node := range nodes {
+		if node.IP == ip {
+			return node, nil
+		}
+	}
+	return Node{}, fmt.Errorf("node with ip %s not found", ip)
+}
+
 // GetNodeByAbsNum finds a node based on its absolute node number
 func GetNodeByAbsNum(nodes []Node, absNum int) (Node, error) {
 	for _, node := range nodes {
@@ -37,6 +37,10 @@ type Server struct {
 	ID int `json:"id"`
 	// SubnetID is the number used in the IP scheme for nodes on this server
 	SubnetID int `json:"subnetID"`
+	// Cpus is the number of cpus the server has, used to weigh the placement of nodes
+	Cpus int `json:"cpus,omitempty"`
+	// Memory is the amount of RAM the server has in bytes, used to weigh the placement of nodes
+	Memory int64 `json:"memory,omitempty"`
 }
 
 // Validate ensures that the  server object contains valid data
@@ -54,13 +58,19 @@ func (s Server) Validate() error {
 	if s.SubnetID < 1 {
 		return fmt.Errorf("invalid SubnetID")
 	}
+	if s.Cpus < 0 {
+		return fmt.Errorf("invalid cpus")
+	}
+	if s.Memory < 0 {
+		return fmt.Errorf("invalid memory")
+	}
 	return nil
 }
 
 // GetAllServers gets all of the servers, indexed by name
 func GetAllServers() (map[string]Server, error) {
 
-	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name FROM %s", ServerTable))
+	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name,cpus,memory FROM %s", ServerTable))
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +80,7 @@ func GetAllServers() (map[string]Server, error) {
 		var name string
 		var server Server
 		err := rows.Scan(&server.ID, &server.SubnetID, &server.Addr,
-			&server.Nodes, &server.Max, &name)
+			&server.Nodes, &server.Max, &name, &server.Cpus, &server.Memory)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -98,7 +108,7 @@ func GetServer(id int) (Server, string, error) {
 	var name string
 	var server Server
 
-	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name FROM %s WHERE id = %d",
+	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name,cpus,memory FROM %s WHERE id = %d",
 		ServerTable, id))
 	if err != nil {
 		return server, name, util.LogError(err)
@@ -109,7 +119,7 @@ func GetServer(id int) (Server, string, error) {
 	}
 	defer rows.Close()
 	err = rows.Scan(&server.ID, &server.SubnetID, &server.Addr,
-		&server.Nodes, &server.Max, &name)
+		&server.Nodes, &server.Max, &name, &server.Cpus, &server.Memory)
 	if err != nil {
 		return server, name, util.LogError(err)
 	}
@@ -125,7 +135,7 @@ func InsertServer(name string, server Server) (int, error) {
 		return -1, util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (addr,server_id,nodes,max,name) VALUES (?,?,?,?,?)", ServerTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (addr,server_id,nodes,max,name,cpus,memory) VALUES (?,?,?,?,?,?,?)", ServerTable))
 	if err != nil {
 		return -1, util.LogError(err)
 	}
@@ -133,7 +143,7 @@ func InsertServer(name string, server Server) (int, error) {
 	defer stmt.Close()
 
 	res, err := stmt.Exec(server.Addr, server.SubnetID,
-		server.Nodes, server.Max, name)
+		server.Nodes, server.Max, name, server.Cpus, server.Memory)
 	if err != nil {
 		return -1, util.LogError(err)
 	}
@@ -157,7 +167,7 @@ func UpdateServer(id int, server Server) error {
 		return util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET server_id = ?,addr = ?, nodes = ?, max = ? WHERE id = ? ", ServerTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET server_id = ?,addr = ?, nodes = ?, max = ?, cpus = ?, memory = ? WHERE id = ? ", ServerTable))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -167,6 +177,8 @@ func UpdateServer(id int, server Server) error {
 		server.Addr,
 		server.Nodes,
 		server.Max,
+		server.Cpus,
+		server.Memory,
 		server.ID)
 	if err != nil {
 		return util.LogError(err)
@@ -197,6 +209,29 @@ func UpdateServerNodes(id int, nodes int) error {
 
 }
 
+//UpdateServerCapacity updates the number of cpus and bytes of memory a server has
+func UpdateServerCapacity(id int, cpus int, memory int64) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET cpus = ?, memory = ? WHERE id = ?", ServerTable))
+
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(cpus, memory, id)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return util.LogError(tx.Commit())
+
+}
+
 //GetHostIPsByTestNet gets the ips of the hosts for a testnet
 func GetHostIPsByTestNet(id int) ([]string, error) {
 
@@ -19,7 +19,6 @@
 package deploy
 
 import (
-	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/testnet"
@@ -37,49 +36,31 @@ func AddNodes(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Provisioning the nodes")
 
-	availableServers := make([]int, len(tn.Servers))
-	for i := range availableServers {
-		availableServers[i] = i
+	strategy, err := GetPlacement(tn.LDD)
+	if err != nil {
+		return util.LogError(err)
 	}
-	index := 0
-
-	for i := 0; i < tn.LDD.Nodes; i++ {
-		serverIndex := availableServers[index]
-		serverID := tn.Servers[serverIndex].ID
-
-		if tn.Servers[serverIndex].Max <= tn.Servers[serverIndex].Nodes {
-			if len(availableServers) == 1 {
-				return fmt.Errorf("cannot build that many nodes with the available resources")
-			}
-			availableServers = append(availableServers[:serverIndex], availableServers[serverIndex+1:]...)
-			i--
-			index = (index + 1) % len(availableServers)
-			continue
-		}
-
+	placements, err := placeNodes(tn.Servers, tn.LDD.Nodes, strategy)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, place := range placements {
 		nodeID, err := util.GetUUIDString()
 		if err != nil {
 			return util.LogError(err)
 		}
 
-		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, len(tn.Nodes), 0)
-		if err != nil {
-			return util.LogError(err)
-		}
-
 		node := tn.AddNode(db.Node{
-			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain})
+			ID: nodeID, TestNetID: tn.TestNetID, Server: tn.Servers[place.server].ID,
+			LocalID: place.localID, IP: place.ip, Protocol: tn.LDD.Blockchain})
 
-		tn.Servers[serverIndex].Nodes++
+		tn.Servers[place.server].Nodes++
 
 		wg.Add(1)
 		go func(server *db.Server, node *db.Node) {
 			defer wg.Done()
 			BuildNode(tn, server, node)
-		}(&tn.Servers[serverIndex], node)
-
-		index = (index + 1) % len(availableServers)
+		}(&tn.Servers[place.server], node)
 	}
 	wg.Wait()
 	distributeNibbler(tn)
@@ -20,7 +20,6 @@
 package deploy
 
 import (
-	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/docker"
@@ -93,25 +92,9 @@ func BuildNode(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 	}
 	tn.BuildState.IncrementDeployProgress()
 
-	var resource util.Resources
-	if len(tn.LDD.Resources) == 0 {
-		resource = util.Resources{Cpus: "", Memory: ""}
-		log.WithFields(log.Fields{"resource": resource, "node": node.AbsoluteNum}).Trace("using default resources")
-	} else {
-		resource = tn.LDD.Resources[0]
-	}
-
-	var env map[string]string
-
-	if len(tn.LDD.Resources) > node.AbsoluteNum {
-		resource = tn.LDD.Resources[node.AbsoluteNum]
-		log.WithFields(log.Fields{"resource": resource, "node": node.AbsoluteNum}).Trace("using given resources")
-	}
-
-	if tn.LDD.Environments != nil && len(tn.LDD.Environments) > node.AbsoluteNum && tn.LDD.Environments[node.AbsoluteNum] != nil {
-		env = tn.LDD.Environments[node.AbsoluteNum]
-		log.WithFields(log.Fields{"env": env, "node": node.AbsoluteNum}).Trace("using custom env vars")
-	}
+	resource := tn.LDD.GetResources(node.AbsoluteNum)
+	env := tn.LDD.GetEnvironment(node.AbsoluteNum)
+	log.WithFields(log.Fields{"resource": resource, "env": env, "node": node.AbsoluteNum}).Trace("using the given resources and env vars")
 	err = docker.Run(tn, server.ID, docker.NewNodeContainer(node, env, resource, server.SubnetID))
 	if err != nil {
 		tn.BuildState.ReportError(err)
@@ -138,49 +121,31 @@ func Build(tn *testnet.TestNet, services []services.Service) error {
 
 	tn.BuildState.SetBuildStage("Provisioning the nodes")
 
-	availableServers := make([]int, len(tn.Servers))
-	for i := range availableServers {
-		availableServers[i] = i
+	strategy, err := GetPlacement(tn.LDD)
+	if err != nil {
+		return util.LogError(err)
 	}
-
-	index := 0
-	for i := 0; i < tn.LDD.Nodes; i++ {
-		serverIndex := availableServers[index]
-		serverID := tn.Servers[serverIndex].ID
-
-		if tn.Servers[serverIndex].Max <= tn.Servers[serverIndex].Nodes {
-			if len(availableServers) == 1 {
-				return util.LogError(fmt.Errorf("cannot build that many nodes with the available resources"))
-			}
-			availableServers = append(availableServers[:serverIndex], availableServers[serverIndex+1:]...)
-			i--
-			index = (index + 1) % len(availableServers)
-			continue
-		}
-
+	placements, err := placeNodes(tn.Servers, tn.LDD.Nodes, strategy)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, place := range placements {
 		nodeID, err := util.GetUUIDString()
 		if err != nil {
 			return util.LogError(err)
 		}
 
-		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, tn.Servers[serverIndex].Nodes, 0)
-		if err != nil {
-			return util.LogError(err)
-		}
-
 		node := tn.AddNode(db.Node{
-			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain})
+			ID: nodeID, TestNetID: tn.TestNetID, Server: tn.Servers[place.server].ID,
+			LocalID: place.localID, IP: place.ip, Protocol: tn.LDD.Blockchain})
 
-		tn.Servers[serverIndex].Nodes++
+		tn.Servers[place.server].Nodes++
 
 		wg.Add(1)
 		go func(server *db.Server, node *db.Node) {
 			defer wg.Done()
 			BuildNode(tn, server, node)
-		}(&tn.Servers[serverIndex], node)
-
-		index = (index + 1) % len(availableServers)
+		}(&tn.Servers[place.server], node)
 	}
 
 	if services != nil { //Maybe distribute the services over multiple servers
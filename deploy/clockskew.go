@@ -0,0 +1,96 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+func getOffset(raw interface{}) (float64, error) {
+	switch offset := raw.(type) {
+	case json.Number:
+		return offset.Float64()
+	case float64:
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("clock offsets must be numbers, got %v", raw)
+	}
+}
+
+// GetClockSkew gets the offset, in seconds, of the clock of each node from the clockSkew extra of the given
+// deployment details. The clock skew can be given either as a single offset for every node, or as an
+// array with the offset of each node. Returns nil if there is no clock skew given.
+func GetClockSkew(details *db.DeploymentDetails) ([]float64, error) {
+	if details.Extras == nil {
+		return nil, nil
+	}
+	raw, ok := details.Extras["clockSkew"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	out := make([]float64, details.Nodes)
+	rawOffsets, ok := raw.([]interface{})
+	if !ok {
+		offset, err := getOffset(raw)
+		if err != nil {
+			return nil, err
+		}
+		for i := range out {
+			out[i] = offset
+		}
+		return out, nil
+	}
+	if len(rawOffsets) != details.Nodes {
+		return nil, fmt.Errorf("expected a clock offset for each of the %d nodes, got %d", details.Nodes, len(rawOffsets))
+	}
+	for i, rawOffset := range rawOffsets {
+		offset, err := getOffset(rawOffset)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %s", i, err.Error())
+		}
+		out[i] = offset
+	}
+	return out, nil
+}
+
+// applyClockSkew skews the clocks of the newly built nodes as given in the extras of the testnet.
+// Nodes without an offset are left alone, so that their main process is not run under libfaketime.
+func applyClockSkew(tn *testnet.TestNet) error {
+	offsets, err := GetClockSkew(tn.LDD)
+	if err != nil || offsets == nil {
+		return util.LogError(err)
+	}
+	first := len(tn.Nodes) - len(tn.NewlyBuiltNodes)
+	return helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		offset := offsets[node.GetAbsoluteNumber()-first]
+		if offset == 0 {
+			return nil
+		}
+		log.WithFields(log.Fields{"node": node.GetAbsoluteNumber(), "offset": offset}).Debug("skewing the clock of a node")
+		return docker.SetClockSkew(client, node, offset)
+	})
+}
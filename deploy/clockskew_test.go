@@ -0,0 +1,62 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func TestGetClockSkew(t *testing.T) {
+	var test = []struct {
+		clockSkew interface{}
+		expected  []float64
+		valid     bool
+	}{
+		{clockSkew: nil, expected: nil, valid: true},
+		{clockSkew: 2.5, expected: []float64{2.5, 2.5, 2.5}, valid: true},
+		{clockSkew: json.Number("-10"), expected: []float64{-10, -10, -10}, valid: true},
+		{clockSkew: []interface{}{0.0, json.Number("1.5"), -3.0}, expected: []float64{0, 1.5, -3}, valid: true},
+		{clockSkew: []interface{}{1.0, 2.0}, valid: false},
+		{clockSkew: []interface{}{1.0, "2", 3.0}, valid: false},
+		{clockSkew: "5s", valid: false},
+	}
+	for i, tt := range test {
+		details := &db.DeploymentDetails{Nodes: 3, Extras: map[string]interface{}{}}
+		if tt.clockSkew != nil {
+			details.Extras["clockSkew"] = tt.clockSkew
+		}
+		offsets, err := GetClockSkew(details)
+		if tt.valid != (err == nil) {
+			t.Errorf("test %d: expected valid to be %v, got error %v", i, tt.valid, err)
+			continue
+		}
+		if tt.valid && !reflect.DeepEqual(offsets, tt.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, tt.expected, offsets)
+		}
+	}
+
+	offsets, err := GetClockSkew(&db.DeploymentDetails{Nodes: 3})
+	if err != nil || offsets != nil {
+		t.Errorf("expected no clock skew without any extras, got %v, %v", offsets, err)
+	}
+}
@@ -38,8 +38,12 @@ import (
    Finalization methods for the docker build process. Will be run immediately following their deployment
 */
 func finalize(tn *testnet.TestNet) error {
+	err := applyClockSkew(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
 	if conf.HandleNodeSSHKeys {
-		err := copyOverSSHKeys(tn, false)
+		err = copyOverSSHKeys(tn, false)
 		if err != nil {
 			return util.LogError(err)
 		}
@@ -53,8 +57,12 @@ func finalize(tn *testnet.TestNet) error {
    Finalization methods for the docker build process. Will be run immediately following their deployment
 */
 func finalizeNewNodes(tn *testnet.TestNet) error {
+	err := applyClockSkew(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
 	if conf.HandleNodeSSHKeys {
-		err := copyOverSSHKeys(tn, true)
+		err = copyOverSSHKeys(tn, true)
 		if err != nil {
 			return util.LogError(err)
 		}
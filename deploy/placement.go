@@ -0,0 +1,245 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"math"
+)
+
+const (
+	// PlaceRoundRobin places the nodes on the servers in turn, the default
+	PlaceRoundRobin = "round-robin"
+	// PlacePack fills up each server, in the given order, before moving on to the next one
+	PlacePack = "pack"
+	// PlaceSpread places each node on the server with the fewest nodes
+	PlaceSpread = "spread"
+	// PlaceWeighted places the nodes on the servers in proportion to their cpus and memory
+	PlaceWeighted = "weighted"
+	// PlacePinned places each node on the server it is explicitly given
+	PlacePinned = "pinned"
+)
+
+// Placement is how the nodes of a build are distributed over its servers
+type Placement struct {
+	// Strategy is one of round-robin, pack, spread, weighted or pinned
+	Strategy string `json:"strategy"`
+	// Servers are the ids of the servers each node is pinned to, for the pinned strategy
+	Servers []int `json:"servers,omitempty"`
+}
+
+// GetPlacement gets the placement strategy requested in the extras of the given deployment details. The
+// placement can be given either as the name of the strategy, or as an object of the form
+// {"strategy":"pinned","servers":[1,2,...]}, where servers pins each node to a server of the build.
+// Defaults to round robin.
+func GetPlacement(details *db.DeploymentDetails) (Placement, error) {
+	out := Placement{Strategy: PlaceRoundRobin}
+	if details.Extras == nil {
+		return out, nil
+	}
+	raw, ok := details.Extras["placement"]
+	if !ok || raw == nil {
+		return out, nil
+	}
+	if name, ok := raw.(string); ok {
+		out.Strategy = name
+	} else {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return Placement{}, util.LogError(err)
+		}
+		err = json.Unmarshal(data, &out)
+		if err != nil {
+			return Placement{}, fmt.Errorf("placement must be either a string or an object")
+		}
+	}
+
+	switch out.Strategy {
+	case PlaceRoundRobin, PlacePack, PlaceSpread, PlaceWeighted:
+		if len(out.Servers) > 0 {
+			return Placement{}, fmt.Errorf("servers can only be given with the pinned placement")
+		}
+	case PlacePinned:
+		if len(out.Servers) != details.Nodes {
+			return Placement{}, fmt.Errorf("expected a server for each of the %d nodes, got %d", details.Nodes, len(out.Servers))
+		}
+		for i, server := range out.Servers {
+			found := false
+			for _, id := range details.Servers {
+				found = found || id == server
+			}
+			if !found {
+				return Placement{}, fmt.Errorf("node %d is pinned to server %d, which is not one of the servers of the build", i, server)
+			}
+		}
+	default:
+		return Placement{}, fmt.Errorf("unknown placement strategy \"%s\"", out.Strategy)
+	}
+	return out, nil
+}
+
+// placement is where a node gets built
+type placement struct {
+	// server is the index of the server in the servers given to placeNodes
+	server  int
+	localID int
+	ip      string
+}
+
+// getWeights gets the share of the total capacity of each server. Only the cpus and memory which every
+// server has recorded are taken into account, and the share of a server is the smaller of the two.
+func getWeights(servers []db.Server) ([]float64, error) {
+	var totalCpus, totalMemory float64
+	useCpus, useMemory := true, true
+	for _, server := range servers {
+		totalCpus += float64(server.Cpus)
+		totalMemory += float64(server.Memory)
+		useCpus = useCpus && server.Cpus > 0
+		useMemory = useMemory && server.Memory > 0
+	}
+	if !useCpus && !useMemory {
+		return nil, fmt.Errorf("weighted placement needs the cpus or memory of every server")
+	}
+	out := make([]float64, len(servers))
+	for i, server := range servers {
+		out[i] = math.Inf(1)
+		if useCpus {
+			out[i] = math.Min(out[i], float64(server.Cpus)/totalCpus)
+		}
+		if useMemory {
+			out[i] = math.Min(out[i], float64(server.Memory)/totalMemory)
+		}
+	}
+	return out, nil
+}
+
+// roundRobin chooses the servers the nodes are placed on in turn, skipping over the servers which are full
+func roundRobin(servers []db.Server, counts []int, nodes int) ([]int, error) {
+	availableServers := make([]int, len(servers))
+	for i := range availableServers {
+		availableServers[i] = i
+	}
+	out := []int{}
+	index := 0
+	for i := 0; i < nodes; i++ {
+		serverIndex := availableServers[index]
+
+		if servers[serverIndex].Max <= counts[serverIndex] {
+			if len(availableServers) == 1 {
+				return nil, fmt.Errorf("cannot build that many nodes with the available resources")
+			}
+			availableServers = append(availableServers[:index], availableServers[index+1:]...)
+			i--
+			index = index % len(availableServers)
+			continue
+		}
+		out = append(out, serverIndex)
+		counts[serverIndex]++
+		index = (index + 1) % len(availableServers)
+	}
+	return out, nil
+}
+
+// chooseServers chooses the index of the server each node is placed on, following the given strategy.
+// counts holds the number of nodes on each server, and is updated as the nodes are placed.
+func chooseServers(servers []db.Server, counts []int, nodes int, strategy Placement) ([]int, error) {
+	if strategy.Strategy == PlaceRoundRobin {
+		return roundRobin(servers, counts, nodes)
+	}
+	var weights []float64
+	if strategy.Strategy == PlaceWeighted {
+		var err error
+		weights, err = getWeights(servers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := []int{}
+	for i := 0; i < nodes; i++ {
+		chosen := -1
+		for j, server := range servers {
+			if server.Max <= counts[j] {
+				continue
+			}
+			switch strategy.Strategy {
+			case PlacePack:
+				if chosen == -1 {
+					chosen = j
+				}
+			case PlaceSpread:
+				if chosen == -1 || counts[j] < counts[chosen] {
+					chosen = j
+				}
+			case PlaceWeighted:
+				//Pick the server which would be the least loaded relative to its capacity
+				if chosen == -1 || float64(counts[j]+1)/weights[j] < float64(counts[chosen]+1)/weights[chosen] {
+					chosen = j
+				}
+			case PlacePinned:
+				if server.ID == strategy.Servers[i] {
+					chosen = j
+				}
+			}
+		}
+		if chosen == -1 && strategy.Strategy == PlacePinned {
+			return nil, fmt.Errorf("node %d cannot be built on server %d, as it is full", i, strategy.Servers[i])
+		}
+		if chosen == -1 {
+			return nil, fmt.Errorf("cannot build that many nodes with the available resources")
+		}
+		out = append(out, chosen)
+		counts[chosen]++
+	}
+	return out, nil
+}
+
+// placeNodes distributes the nodes over the servers following the given strategy, without going over
+// the maximum number of nodes of any server. The node counts of the given servers are left untouched.
+func placeNodes(servers []db.Server, nodes int, strategy Placement) ([]placement, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("there are no servers to build the nodes on")
+	}
+	counts := make([]int, len(servers))
+	for i, server := range servers {
+		counts[i] = server.Nodes
+	}
+	chosen, err := chooseServers(servers, counts, nodes, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	//The local ids continue on from the nodes already on each server
+	for i, server := range servers {
+		counts[i] = server.Nodes
+	}
+	out := []placement{}
+	for _, serverIndex := range chosen {
+		nodeIP, err := util.GetNodeIP(servers[serverIndex].SubnetID, counts[serverIndex], 0)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, placement{server: serverIndex, localID: counts[serverIndex], ip: nodeIP})
+		counts[serverIndex]++
+	}
+	return out, nil
+}
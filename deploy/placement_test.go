@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func getPlacedServers(t *testing.T, servers []db.Server, nodes int, strategy Placement) []int {
+	placements, err := placeNodes(servers, nodes, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := []int{}
+	for _, place := range placements {
+		out = append(out, servers[place.server].ID)
+	}
+	return out
+}
+
+func TestPlaceNodes(t *testing.T) {
+	servers := []db.Server{
+		{ID: 1, SubnetID: 1, Max: 4, Cpus: 8, Memory: 16000000000},
+		{ID: 2, SubnetID: 2, Max: 4, Cpus: 24, Memory: 48000000000},
+		{ID: 3, SubnetID: 3, Nodes: 2, Max: 4, Cpus: 8, Memory: 8000000000},
+	}
+	var test = []struct {
+		strategy Placement
+		expected []int
+	}{
+		{strategy: Placement{Strategy: PlaceRoundRobin}, expected: []int{1, 2, 3, 1, 2, 3, 1}},
+		{strategy: Placement{Strategy: PlacePack}, expected: []int{1, 1, 1, 1, 2, 2, 2}},
+		{strategy: Placement{Strategy: PlaceSpread}, expected: []int{1, 2, 1, 2, 1, 2, 3}},
+		{strategy: Placement{Strategy: PlaceWeighted}, expected: []int{2, 2, 1, 2, 2, 1, 1}},
+		{strategy: Placement{Strategy: PlacePinned, Servers: []int{3, 3, 1, 1, 2, 2, 2}}, expected: []int{3, 3, 1, 1, 2, 2, 2}},
+	}
+	for _, tt := range test {
+		placed := getPlacedServers(t, servers, 7, tt.strategy)
+		if !reflect.DeepEqual(placed, tt.expected) {
+			t.Errorf("%s: expected the nodes to be placed on %v, got %v", tt.strategy.Strategy, tt.expected, placed)
+		}
+	}
+
+	placements, err := placeNodes(servers, 2, Placement{Strategy: PlacePinned, Servers: []int{3, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placements[0].localID != 2 || placements[1].localID != 3 {
+		t.Errorf("expected the nodes to follow on from the nodes already on the server, got %v", placements)
+	}
+
+	for _, strategy := range []string{PlaceRoundRobin, PlacePack, PlaceSpread, PlaceWeighted} {
+		_, err = placeNodes(servers, 11, Placement{Strategy: strategy})
+		if err == nil {
+			t.Errorf("%s: expected an error when the servers are full", strategy)
+		}
+	}
+	_, err = placeNodes(servers, 3, Placement{Strategy: PlacePinned, Servers: []int{3, 3, 3}})
+	if err == nil {
+		t.Error("expected an error when a node is pinned to a full server")
+	}
+	_, err = placeNodes([]db.Server{{ID: 1, SubnetID: 1, Max: 4}}, 1, Placement{Strategy: PlaceWeighted})
+	if err == nil {
+		t.Error("expected an error when weighing servers without any capacity")
+	}
+}
+
+func TestGetPlacement(t *testing.T) {
+	var test = []struct {
+		placement interface{}
+		valid     bool
+	}{
+		{placement: nil, valid: true},
+		{placement: "pack", valid: true},
+		{placement: "fill", valid: false},
+		{placement: map[string]interface{}{"strategy": "pinned", "servers": []interface{}{1.0, 2.0}}, valid: true},
+		{placement: map[string]interface{}{"strategy": "pinned", "servers": []interface{}{1.0}}, valid: false},
+		{placement: map[string]interface{}{"strategy": "pinned", "servers": []interface{}{1.0, 3.0}}, valid: false},
+		{placement: map[string]interface{}{"strategy": "spread", "servers": []interface{}{1.0, 2.0}}, valid: false},
+		{placement: 5.0, valid: false},
+	}
+	for i, tt := range test {
+		details := &db.DeploymentDetails{Servers: []int{1, 2}, Nodes: 2, Extras: map[string]interface{}{"placement": tt.placement}}
+		_, err := GetPlacement(details)
+		if (err == nil) != tt.valid {
+			t.Errorf("%d: expected valid to be %v, got error %v", i, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/protocols/services"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// SideCarPlan is how a side car of a node would be built
+type SideCarPlan struct {
+	Type    string `json:"type"`
+	IP      string `json:"ip"`
+	Image   string `json:"image"`
+	Command string `json:"command"`
+}
+
+// NodePlan is where and how a node would be built
+type NodePlan struct {
+	AbsoluteNum int               `json:"absoluteNum"`
+	Server      int               `json:"server"`
+	LocalID     int               `json:"localId"`
+	IP          string            `json:"ip"`
+	Image       string            `json:"image"`
+	Resources   util.Resources    `json:"resources"`
+	Environment map[string]string `json:"environment,omitempty"`
+	// Command is the docker run command which starts the node
+	Command  string        `json:"command"`
+	SideCars []SideCarPlan `json:"sidecars,omitempty"`
+}
+
+// ServicePlan is a service which would be started alongside the nodes
+type ServicePlan struct {
+	Name    string            `json:"name"`
+	Image   string            `json:"image"`
+	Env     map[string]string `json:"env"`
+	Ports   []string          `json:"ports"`
+	Volumes []string          `json:"volumes"`
+	Command string            `json:"command"`
+}
+
+// BuildPlan is everything Build would do for the given deployment details
+type BuildPlan struct {
+	// Servers are the servers with the number of nodes they would have after the build
+	Servers  []db.Server   `json:"servers"`
+	Nodes    []NodePlan    `json:"nodes"`
+	Services []ServicePlan `json:"services"`
+}
+
+// Plan works out where and how Build would build the nodes of the given deployment details, without
+// touching any of the servers. The details should already be validated.
+func Plan(details *db.DeploymentDetails, services []services.Service) (BuildPlan, error) {
+	servers, err := db.GetServers(details.Servers)
+	if err != nil {
+		return BuildPlan{}, util.LogError(err)
+	}
+	strategy, err := GetPlacement(details)
+	if err != nil {
+		return BuildPlan{}, util.LogError(err)
+	}
+	placements, err := placeNodes(servers, details.Nodes, strategy)
+	if err != nil {
+		return BuildPlan{}, util.LogError(err)
+	}
+	//The side cars of a blockchain only depend on the deployment details
+	sidecars, err := registrar.GetBlockchainSideCars(&testnet.TestNet{LDD: details, CombinedDetails: *details})
+	if err != nil {
+		sidecars = nil //Not an error, the blockchain doesn't have any sidecars
+	}
+
+	out := BuildPlan{Nodes: []NodePlan{}, Services: []ServicePlan{}}
+	for i, place := range placements {
+		server := servers[place.server]
+		node := db.Node{
+			AbsoluteNum: i,
+			Server:      server.ID,
+			LocalID:     place.localID,
+			IP:          place.ip,
+			Image:       details.GetImage(i),
+			Protocol:    details.Blockchain,
+		}
+		nodePlan := NodePlan{
+			AbsoluteNum: i,
+			Server:      server.ID,
+			LocalID:     node.LocalID,
+			IP:          node.IP,
+			Image:       node.Image,
+			Resources:   details.GetResources(i),
+			Environment: details.GetEnvironment(i),
+		}
+		nodePlan.Command, err = docker.RunCommand(docker.NewNodeContainer(&node, nodePlan.Environment,
+			nodePlan.Resources, server.SubnetID))
+		if err != nil {
+			return BuildPlan{}, util.LogError(err)
+		}
+
+		for j, sidecar := range sidecars {
+			sideCarDetails, err := registrar.GetSideCar(sidecar)
+			if err != nil {
+				return BuildPlan{}, util.LogError(err)
+			}
+			sidecarIP, err := util.GetNodeIP(server.SubnetID, node.LocalID, j+1)
+			if err != nil {
+				return BuildPlan{}, util.LogError(err)
+			}
+			sc := db.SideCar{
+				AbsoluteNodeNum: i,
+				Server:          server.ID,
+				LocalID:         node.LocalID,
+				NetworkIndex:    j + 1,
+				IP:              sidecarIP,
+				Image:           sideCarDetails.Image,
+				Type:            sidecar,
+			}
+			command, err := docker.RunCommand(docker.NewSideCarContainer(&sc, nil, util.Resources{}, server.SubnetID))
+			if err != nil {
+				return BuildPlan{}, util.LogError(err)
+			}
+			nodePlan.SideCars = append(nodePlan.SideCars, SideCarPlan{
+				Type: sidecar, IP: sidecarIP, Image: sc.Image, Command: command})
+		}
+		servers[place.server].Nodes++
+		out.Nodes = append(out.Nodes, nodePlan)
+	}
+	out.Servers = servers
+
+	for _, service := range services {
+		out.Services = append(out.Services, ServicePlan{
+			Name:    service.GetName(),
+			Image:   service.GetImage(),
+			Env:     service.GetEnv(),
+			Ports:   service.GetPorts(),
+			Volumes: service.GetVolumes(),
+			Command: service.GetCommand(),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// GetTopology gets the topology preset requested in the extras of the given deployment details,
+// along with the index of the region each node is placed in. The topology can be given either as the
+// name of the preset, or as an object of the form {"preset":"us-eu-asia","regions":["us-east",...]}, where
+// regions explicitly places each node. Returns nil if there is no topology given.
+func GetTopology(details *db.DeploymentDetails) (*netem.Topology, []int, error) {
+	if details.Extras == nil {
+		return nil, nil, nil
+	}
+	raw, ok := details.Extras["topology"]
+	if !ok || raw == nil {
+		return nil, nil, nil
+	}
+	name, ok := raw.(string)
+	if ok {
+		topology, err := netem.GetTopology(name)
+		if err != nil {
+			return nil, nil, util.LogError(err)
+		}
+		return &topology, topology.Assign(details.Nodes), nil
+	}
+
+	obj, ok := util.ExtractStringMap(details.Extras, "topology")
+	if !ok {
+		return nil, nil, fmt.Errorf("topology must be either a string or an object")
+	}
+	name, ok = obj["preset"].(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("topology is missing the preset")
+	}
+	topology, err := netem.GetTopology(name)
+	if err != nil {
+		return nil, nil, util.LogError(err)
+	}
+
+	rawRegions, ok := obj["regions"].([]interface{})
+	if !ok {
+		return &topology, topology.Assign(details.Nodes), nil
+	}
+	if len(rawRegions) != details.Nodes {
+		return nil, nil, fmt.Errorf("expected a region for each of the %d nodes, got %d", details.Nodes, len(rawRegions))
+	}
+	assignment := make([]int, len(rawRegions))
+	for i, rawRegion := range rawRegions {
+		region, ok := rawRegion.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("region for node %d must be a string", i)
+		}
+		assignment[i], err = topology.GetRegionIndex(region)
+		if err != nil {
+			return nil, nil, util.LogError(err)
+		}
+	}
+	return &topology, assignment, nil
+}
+
+// ApplyTopology applies the network conditions of the topology preset given in the extras of
+// the testnet, if there is one.
+func ApplyTopology(tn *testnet.TestNet) error {
+	topology, assignment, err := GetTopology(tn.LDD)
+	if err != nil || topology == nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildStage("Applying the network topology")
+	log.WithFields(log.Fields{"build": tn.TestNetID, "topology": topology.Name}).Info("applying the topology")
+
+	links, err := topology.CreateLinks(assignment)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return util.LogError(netem.ApplyLinks(links, tn.Nodes))
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"strconv"
+	"strings"
+)
+
+// SetClockSkew sets the offset, in seconds, of the clock seen by the main process of a node. The
+// first time the clock of a node is skewed, libfaketime is copied over from the server into the node.
+// libfaketime is only loaded when the main process starts, so the process must be restarted for that
+// first offset to take effect; later offsets take effect within a second.
+func SetClockSkew(client ssh.Client, node ssh.Node, offset float64) error {
+	_, err := client.Run(fmt.Sprintf("docker exec %s test -f %s || docker cp %s %s:%s", node.GetNodeName(),
+		ssh.FaketimeLibrary, conf.FaketimeLibrary, node.GetNodeName(), ssh.FaketimeLibrary))
+	if err != nil {
+		return err
+	}
+	_, err = client.DockerExec(node, fmt.Sprintf("sh -c 'echo %+.3f > %s'", offset, ssh.FaketimeFile))
+	return err
+}
+
+// GetClockSkew gets the offset, in seconds, of the clock of a node. Returns false if the clock of the
+// node has never been skewed.
+func GetClockSkew(client ssh.Client, node ssh.Node) (float64, bool, error) {
+	res, err := client.DockerExec(node, fmt.Sprintf("sh -c '[ ! -f %s ] || cat %s'", ssh.FaketimeFile, ssh.FaketimeFile))
+	if err != nil {
+		return 0, false, err
+	}
+	res = strings.TrimSpace(res)
+	if len(res) == 0 {
+		return 0, false, nil
+	}
+	offset, err := strconv.ParseFloat(res, 64)
+	return offset, true, err
+}
@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func TestGetClockSkew(t *testing.T) {
+	var test = []struct {
+		output string
+		offset float64
+		skewed bool
+		valid  bool
+	}{
+		{output: "", offset: 0, skewed: false, valid: true},
+		{output: "+2.500\n", offset: 2.5, skewed: true, valid: true},
+		{output: "-10.000\n", offset: -10, skewed: true, valid: true},
+		{output: "+0.000", offset: 0, skewed: true, valid: true},
+		{output: "garbage\n", skewed: true, valid: false},
+	}
+	for i, tt := range test {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockClient(ctrl)
+		node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0}
+		client.EXPECT().DockerExec(node, fmt.Sprintf("sh -c '[ ! -f %s ] || cat %s'",
+			ssh.FaketimeFile, ssh.FaketimeFile)).Return(tt.output, nil)
+
+		offset, skewed, err := GetClockSkew(client, node)
+		if tt.valid != (err == nil) {
+			t.Errorf("test %d: expected valid to be %v, got error %v", i, tt.valid, err)
+		}
+		if skewed != tt.skewed {
+			t.Errorf("test %d: expected skewed to be %v, got %v", i, tt.skewed, skewed)
+		}
+		if tt.valid && offset != tt.offset {
+			t.Errorf("test %d: expected an offset of %f, got %f", i, tt.offset, offset)
+		}
+		ctrl.Finish()
+	}
+}
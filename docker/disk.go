@@ -0,0 +1,82 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+)
+
+// fillFile is the name of the file used to fill up a directory
+const fillFile = ".genesis-fill"
+
+// getAvailableSpace gets the number of bytes available on the filesystem holding the given directory of a node
+func getAvailableSpace(client ssh.Client, node ssh.Node, dir string) (int64, error) {
+	res, err := client.DockerExec(node, fmt.Sprintf("df -Pk %s", util.ShellQuote(dir)))
+	if err != nil {
+		return -1, err
+	}
+	lines := strings.Split(strings.TrimSpace(res), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return -1, fmt.Errorf("unexpected output from df: %s", res)
+	}
+	kb, err := strconv.ParseInt(fields[3], 10, 64)
+	return kb * 1024, err
+}
+
+// FillDisk fills the filesystem holding the given directory of a node with a file of the given number of bytes.
+// If size is negative, the file takes up all of the available space except for the given number of free bytes.
+// Nodes usually share the filesystem of the server, in which case filling it up affects every node on the server.
+// Returns the size of the file created. Images without fallocate get a file which is a whole number of MiB, rounded
+// up for a given size, and rounded down when filling the available space. If the file cannot be created, whatever
+// was written of it is removed.
+func FillDisk(client ssh.Client, node ssh.Node, dir string, size int64, free int64) (int64, error) {
+	count := (size + 1048575) / 1048576
+	if size < 0 {
+		available, err := getAvailableSpace(client, node, dir)
+		if err != nil {
+			return -1, err
+		}
+		size = available - free
+		count = size / 1048576
+	}
+	if size <= 0 {
+		return 0, nil
+	}
+	file := util.ShellQuote(fmt.Sprintf("%s/%s", strings.TrimSuffix(dir, "/"), fillFile))
+	//Not every image has fallocate, dd is much slower but always available
+	_, err := client.DockerExec(node, fmt.Sprintf("sh -c %s", util.ShellQuote(fmt.Sprintf(
+		"fallocate -l %d %s || dd if=/dev/zero of=%s bs=1048576 count=%d", size, file, file, count))))
+	if err != nil {
+		ClearDisk(client, node, dir)
+		return -1, err
+	}
+	return size, nil
+}
+
+// ClearDisk removes the file created by FillDisk from the given directory of a node
+func ClearDisk(client ssh.Client, node ssh.Node, dir string) error {
+	_, err := client.DockerExec(node, fmt.Sprintf("rm -f %s",
+		util.ShellQuote(fmt.Sprintf("%s/%s", strings.TrimSuffix(dir, "/"), fillFile))))
+	return err
+}
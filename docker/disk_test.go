@@ -0,0 +1,71 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func TestFillDisk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0}
+	client.EXPECT().DockerExec(node, `sh -c 'fallocate -l 4096 '\''/data/'\''\'\'''\''; rm -rf /.genesis-fill'\'' || `+
+		`dd if=/dev/zero of='\''/data/'\''\'\'''\''; rm -rf /.genesis-fill'\'' bs=1048576 count=1'`).Return("", nil)
+	client.EXPECT().DockerExec(node, "df -Pk '/data'").Return(
+		"Filesystem     1024-blocks    Used Available Capacity Mounted on\n"+
+			"overlay          100000000 5000000     3072      99% /\n", nil)
+	client.EXPECT().DockerExec(node, `sh -c 'fallocate -l 2097152 '\''/data/.genesis-fill'\'' || `+
+		`dd if=/dev/zero of='\''/data/.genesis-fill'\'' bs=1048576 count=2'`).Return("", nil)
+
+	size, err := FillDisk(client, node, "/data/'; rm -rf /", 4096, 0)
+	if err != nil || size != 4096 {
+		t.Errorf("expected a 4096 byte file, got %d, %v", size, err)
+	}
+	size, err = FillDisk(client, node, "/data", -1, 1048576)
+	if err != nil || size != 2097152 {
+		t.Errorf("expected a file filling all but the free space, got %d, %v", size, err)
+	}
+}
+
+func TestFillDisk_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0}
+	client.EXPECT().DockerExec(node, "df -Pk '/data'").Return(
+		"Filesystem     1024-blocks    Used Available Capacity Mounted on\n"+
+			"overlay          100000000 5000000     3000      99% /\n", nil)
+	client.EXPECT().DockerExec(node, `sh -c 'fallocate -l 3072000 '\''/data/.genesis-fill'\'' || `+
+		`dd if=/dev/zero of='\''/data/.genesis-fill'\'' bs=1048576 count=2'`).Return("", fmt.Errorf("no space left on device"))
+	client.EXPECT().DockerExec(node, "rm -f '/data/.genesis-fill'").Return("", nil)
+
+	_, err := FillDisk(client, node, "/data", -1, 0)
+	if err == nil {
+		t.Errorf("expected the failure to fill the disk to be reported")
+	}
+}
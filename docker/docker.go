@@ -28,6 +28,7 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"sort"
 	"strings"
 )
 
@@ -39,6 +40,36 @@ func KillNode(client ssh.Client, node int) error {
 	return err
 }
 
+// PauseNode freezes all of the processes of a single node by index on a server
+func PauseNode(client ssh.Client, node int) error {
+	_, err := client.Run(fmt.Sprintf("docker pause %s%d", conf.NodePrefix, node))
+	return err
+}
+
+// UnpauseNode resumes all of the processes of a single node paused by PauseNode
+func UnpauseNode(client ssh.Client, node int) error {
+	_, err := client.Run(fmt.Sprintf("docker unpause %s%d", conf.NodePrefix, node))
+	return err
+}
+
+// UpdateResources changes the cpu and memory limits of a running node by index on a server
+func UpdateResources(client ssh.Client, node int, res util.Resources) error {
+	command := "docker update"
+	if !res.NoCPULimits() {
+		command += fmt.Sprintf(" --cpus %s", res.Cpus)
+	}
+	if !res.NoMemoryLimits() {
+		mem, err := res.GetMemory()
+		if err != nil {
+			return fmt.Errorf("invalid value for memory")
+		}
+		//Keep the same ratio of swap to memory which docker run defaults to
+		command += fmt.Sprintf(" --memory %d --memory-swap %d", mem, 2*mem)
+	}
+	_, err := client.Run(fmt.Sprintf("%s %s%d", command, conf.NodePrefix, node))
+	return err
+}
+
 //Kill kills a node and all of its sidecars
 func Kill(client ssh.Client, node int) error {
 	_, err := client.Run(fmt.Sprintf("docker rm -f $(docker ps -aq -f name=\"%s%d\")", conf.NodePrefix, node))
@@ -143,6 +174,21 @@ func dockerRunCmd(c Container) (string, error) {
 		}
 		command += fmt.Sprintf(" --memory %d", mem)
 	}
+
+	if !c.GetResources().NoDiskLimits() {
+		limits, err := c.GetResources().GetDiskLimits()
+		if err != nil {
+			return "", util.LogError(err)
+		}
+		flags := []string{}
+		for flag := range limits {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		for _, flag := range flags {
+			command += fmt.Sprintf(" --%s %s:%d", flag, c.GetResources().GetDevice(), limits[flag])
+		}
+	}
 	for key, value := range c.GetEnvironment() {
 		command += fmt.Sprintf(" -e \"%s=%s\"", key, value)
 	}
@@ -157,6 +203,11 @@ func dockerRunCmd(c Container) (string, error) {
 	return command, nil
 }
 
+// RunCommand gets the docker run command which Run uses to start the container, without running it
+func RunCommand(container Container) (string, error) {
+	return dockerRunCmd(container)
+}
+
 // Run starts a node
 func Run(tn *testnet.TestNet, serverID int, container Container) error {
 	command, err := dockerRunCmd(container)
@@ -0,0 +1,298 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package inventory keeps track of the capacity and health of the registered servers
+package inventory
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// probeCmd gathers everything about a server in a single round trip, one labelled line per fact. It is
+// given the prefix of the names of the node containers.
+const probeCmd = `echo "cpus $(nproc)";` +
+	`echo "memory $(awk '/^MemTotal:/{print $2}' /proc/meminfo) $(awk '/^MemAvailable:/{print $2}' /proc/meminfo)";` +
+	`echo "load $(cut -d' ' -f1-3 /proc/loadavg)";` +
+	`echo "docker $(docker version --format '{{.Server.Version}}' 2>/dev/null)";` +
+	`echo "disk $(df -B1 --output=size,avail $(docker info --format '{{.DockerRootDir}}' 2>/dev/null || echo /) | tail -n1)";` +
+	`echo "tc $(sudo -n tc qdisc show >/dev/null 2>&1 && echo yes)";` +
+	`echo "netem $(sudo -n modprobe -n sch_netem >/dev/null 2>&1 && echo yes)";` +
+	`echo "iptables $(sudo -n iptables -L -n >/dev/null 2>&1 && echo yes)";` +
+	`ids=$(docker ps -q -f name=%s 2>/dev/null);` +
+	`[ -z "$ids" ] || docker stats --no-stream --format "node {{.CPUPerc}} {{.MemUsage}}" $ids`
+
+// nodeDisk is the disk space in bytes set aside for each node, as the disk usage of a node can't be limited
+const nodeDisk = 256 * 1024 * 1024
+
+// dockerSizes are the multipliers of the units docker stats gives sizes in
+var dockerSizes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"kB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12}, {"B", 1},
+}
+
+// Inventory is the capacity and health of a server, as of when it was last probed
+type Inventory struct {
+	Server int `json:"server"`
+	Cpus   int `json:"cpus"`
+	// Memory is the total amount of RAM in bytes
+	Memory int64 `json:"memory"`
+	// MemoryAvailable is the amount of RAM in bytes which can be used without swapping
+	MemoryAvailable int64 `json:"memoryAvailable"`
+	// Disk is the size in bytes of the filesystem docker stores its containers on
+	Disk          int64  `json:"disk"`
+	DiskAvailable int64  `json:"diskAvailable"`
+	DockerVersion string `json:"dockerVersion"`
+	// Tc, Netem and Iptables are whether the network conditions and outages can be applied on the server
+	Tc       bool `json:"tc"`
+	Netem    bool `json:"netem"`
+	Iptables bool `json:"iptables"`
+	// Load is the 1, 5 and 15 minute load averages
+	Load [3]float64 `json:"load"`
+	// NodeCpus and NodeMemory are the cpus and bytes of memory in use by the nodes on the server, which are
+	// torn down when a testnet is built on it
+	NodeCpus   float64 `json:"nodeCpus"`
+	NodeMemory int64   `json:"nodeMemory"`
+	Healthy    bool    `json:"healthy"`
+	// Problems are the reasons the server is not healthy
+	Problems []string  `json:"problems"`
+	Probed   time.Time `json:"probed"`
+}
+
+func inventoryKey(serverID int) string {
+	return fmt.Sprintf("inventory_%d", serverID)
+}
+
+// parseDockerSize parses a size given by docker stats, ie "1.5GiB"
+func parseDockerSize(raw string) (int64, error) {
+	for _, size := range dockerSizes {
+		if strings.HasSuffix(raw, size.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(raw, size.suffix), 64)
+			return int64(value * size.multiplier), err
+		}
+	}
+	return 0, fmt.Errorf("unknown size %s", raw)
+}
+
+// parse fills in the inventory from the output of probeCmd
+func (inv *Inventory) parse(output string) error {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		values := fields[1:]
+		var err error
+		switch fields[0] {
+		case "cpus":
+			if len(values) > 0 {
+				inv.Cpus, err = strconv.Atoi(values[0])
+			}
+		case "memory": //in kB
+			if len(values) > 1 {
+				inv.MemoryAvailable, err = strconv.ParseInt(values[1], 10, 64)
+				inv.MemoryAvailable *= 1024
+			}
+			if err == nil && len(values) > 0 {
+				inv.Memory, err = strconv.ParseInt(values[0], 10, 64)
+				inv.Memory *= 1024
+			}
+		case "load":
+			for i := 0; i < len(values) && i < len(inv.Load) && err == nil; i++ {
+				inv.Load[i], err = strconv.ParseFloat(values[i], 64)
+			}
+		case "docker":
+			inv.DockerVersion = strings.Join(values, " ")
+		case "disk":
+			if len(values) > 1 {
+				inv.DiskAvailable, err = strconv.ParseInt(values[1], 10, 64)
+			}
+			if err == nil && len(values) > 0 {
+				inv.Disk, err = strconv.ParseInt(values[0], 10, 64)
+			}
+		case "tc":
+			inv.Tc = len(values) > 0
+		case "netem":
+			inv.Netem = len(values) > 0
+		case "iptables":
+			inv.Iptables = len(values) > 0
+		case "node": //cpu percentage and memory usage of a node
+			if len(values) < 2 {
+				err = fmt.Errorf("missing usage")
+				break
+			}
+			var cpus float64
+			var memory int64
+			cpus, err = strconv.ParseFloat(strings.TrimSuffix(values[0], "%"), 64)
+			if err == nil {
+				memory, err = parseDockerSize(values[1])
+			}
+			inv.NodeCpus += cpus / 100
+			inv.NodeMemory += memory
+		}
+		if err != nil {
+			return fmt.Errorf("unexpected %s from the server: %s", fields[0], strings.Join(values, " "))
+		}
+	}
+	return nil
+}
+
+// check works out whether the server is healthy
+func (inv *Inventory) check() {
+	if inv.Cpus == 0 || inv.Memory == 0 {
+		inv.Problems = append(inv.Problems, "could not find the cpus and memory of the server")
+	}
+	if len(inv.DockerVersion) == 0 {
+		inv.Problems = append(inv.Problems, "docker is not running")
+	}
+	if !inv.Tc {
+		inv.Problems = append(inv.Problems, "tc cannot be run with sudo")
+	}
+	if !inv.Netem {
+		inv.Problems = append(inv.Problems, "the netem qdisc is not available")
+	}
+	if !inv.Iptables {
+		inv.Problems = append(inv.Problems, "iptables cannot be run with sudo")
+	}
+	inv.Healthy = len(inv.Problems) == 0
+}
+
+// Fits checks that the given number of nodes, needing the given number of cpus and bytes of memory in total,
+// fit on the server, which must be healthy. Zero cpus or memory means the nodes have no limit. The load on
+// the server from anything other than its current nodes, which a build tears down, is taken into account.
+func (inv Inventory) Fits(nodes int, cpus float64, memory int64) error {
+	if !inv.Healthy {
+		return fmt.Errorf("server %d is not healthy: %s", inv.Server, strings.Join(inv.Problems, ", "))
+	}
+	if inv.Cpus > 0 {
+		free := float64(inv.Cpus)
+		if otherLoad := inv.Load[0] - inv.NodeCpus; otherLoad > 0 {
+			free -= otherLoad
+		}
+		if cpus > free {
+			return fmt.Errorf("server %d has %g of its %d cpus free, but its nodes need %g", inv.Server, free,
+				inv.Cpus, cpus)
+		}
+	}
+	if inv.Memory > 0 {
+		free := inv.Memory
+		if inv.MemoryAvailable > 0 {
+			free = inv.MemoryAvailable + inv.NodeMemory
+		}
+		if memory > free {
+			return fmt.Errorf("server %d has %d bytes of memory free, but its nodes need %d", inv.Server, free, memory)
+		}
+	}
+	if inv.Disk > 0 && int64(nodes)*nodeDisk > inv.DiskAvailable {
+		return fmt.Errorf("server %d has %d bytes of disk space free, but its %d nodes need %d", inv.Server,
+			inv.DiskAvailable, nodes, int64(nodes)*nodeDisk)
+	}
+	return nil
+}
+
+// Probe gathers the capacity and health of the given server over ssh, and stores it. The cpus and
+// memory of the server are updated to match. An unreachable server gives an unhealthy inventory,
+// rather than an error.
+func Probe(serverID int) (Inventory, error) {
+	inv := Inventory{Server: serverID, Problems: []string{}, Probed: time.Now()}
+	server, _, err := db.GetServer(serverID)
+	if err != nil {
+		return Inventory{}, util.LogError(err)
+	}
+	output, err := probe(serverID)
+	if err == nil {
+		err = inv.parse(output)
+	}
+	if err != nil {
+		inv.Problems = append(inv.Problems, err.Error())
+	} else {
+		inv.check()
+	}
+
+	err = db.SetMeta(inventoryKey(serverID), inv)
+	if err != nil {
+		return Inventory{}, util.LogError(err)
+	}
+	if inv.Cpus > 0 && inv.Memory > 0 && (server.Cpus != inv.Cpus || server.Memory != inv.Memory) {
+		err = db.UpdateServerCapacity(serverID, inv.Cpus, inv.Memory)
+		if err != nil {
+			return Inventory{}, util.LogError(err)
+		}
+	}
+	return inv, nil
+}
+
+func probe(serverID int) (string, error) {
+	client, err := status.GetClient(serverID)
+	if err != nil {
+		return "", fmt.Errorf("could not connect to the server: %s", err.Error())
+	}
+	return client.Run(fmt.Sprintf(probeCmd, conf.NodePrefix))
+}
+
+// Get gets the stored inventory of the given server, false if the server has never been probed
+func Get(serverID int) (Inventory, bool) {
+	out := Inventory{}
+	err := db.GetMetaP(inventoryKey(serverID), &out)
+	if err != nil {
+		log.WithFields(log.Fields{"server": serverID}).Debug("no stored inventory")
+		return Inventory{}, false
+	}
+	return out, true
+}
+
+// ProbeAll probes all of the registered servers
+func ProbeAll() {
+	servers, err := db.GetAllServers()
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+	for _, server := range servers {
+		inv, err := Probe(server.ID)
+		if err != nil {
+			continue
+		}
+		if !inv.Healthy {
+			log.WithFields(log.Fields{"server": server.ID, "problems": inv.Problems}).Warn("server is unhealthy")
+		}
+	}
+}
+
+// Monitor probes all of the registered servers every inventoryInterval seconds, it does not return.
+// Does nothing if the interval is not positive.
+func Monitor() {
+	if conf.InventoryInterval <= 0 {
+		return
+	}
+	for {
+		ProbeAll()
+		time.Sleep(time.Duration(conf.InventoryInterval) * time.Second)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package inventory
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInventory_Parse(t *testing.T) {
+	output := "cpus 16\n" +
+		"memory 65843296 48174812\n" +
+		"load 0.52 0.61 0.70\n" +
+		"docker 18.09.7\n" +
+		"disk 502468108288 311385251840\n" +
+		"tc yes\n" +
+		"netem \n" +
+		"iptables yes\n" +
+		"node 150.25% 1.5GiB / 62.79GiB\n" +
+		"node 0.50% 512MiB / 62.79GiB\n"
+	inv := Inventory{Server: 1}
+	err := inv.parse(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Inventory{
+		Server:          1,
+		Cpus:            16,
+		Memory:          65843296 * 1024,
+		MemoryAvailable: 48174812 * 1024,
+		Disk:            502468108288,
+		DiskAvailable:   311385251840,
+		DockerVersion:   "18.09.7",
+		Tc:              true,
+		Iptables:        true,
+		Load:            [3]float64{0.52, 0.61, 0.70},
+		NodeCpus:        1.5075,
+		NodeMemory:      2 * 1024 * 1024 * 1024,
+	}
+	if inv.Cpus != expected.Cpus || inv.Memory != expected.Memory || inv.MemoryAvailable != expected.MemoryAvailable ||
+		inv.Disk != expected.Disk || inv.DiskAvailable != expected.DiskAvailable || inv.DockerVersion != expected.DockerVersion ||
+		inv.Tc != expected.Tc || inv.Netem != expected.Netem || inv.Iptables != expected.Iptables || inv.Load != expected.Load ||
+		math.Abs(inv.NodeCpus-expected.NodeCpus) > 1e-9 || inv.NodeMemory != expected.NodeMemory {
+		t.Errorf("expected %+v, got %+v", expected, inv)
+	}
+
+	inv.check()
+	if inv.Healthy || len(inv.Problems) != 1 {
+		t.Errorf("expected the missing netem qdisc to be the only problem, got %v", inv.Problems)
+	}
+
+	err = inv.parse("cpus many\n")
+	if err == nil {
+		t.Error("expected an error for an invalid number of cpus")
+	}
+}
+
+func TestInventory_Fits(t *testing.T) {
+	inv := Inventory{Server: 1, Cpus: 8, Memory: 16000000000, MemoryAvailable: 6000000000, Disk: 100000000000,
+		DiskAvailable: 2 * nodeDisk, Load: [3]float64{3, 3, 3}, NodeCpus: 1, NodeMemory: 4000000000, Healthy: true}
+	var test = []struct {
+		nodes  int
+		cpus   float64
+		memory int64
+		fits   bool
+	}{
+		{nodes: 2, cpus: 6, memory: 10000000000, fits: true},
+		{nodes: 0, cpus: 0, memory: 0, fits: true},
+		{nodes: 1, cpus: 6.5, memory: 0, fits: false},
+		{nodes: 1, cpus: 0, memory: 10000000001, fits: false},
+		{nodes: 3, cpus: 0, memory: 0, fits: false},
+	}
+	for i, tt := range test {
+		err := inv.Fits(tt.nodes, tt.cpus, tt.memory)
+		if (err == nil) != tt.fits {
+			t.Errorf("%d: expected fits to be %v, got error %v", i, tt.fits, err)
+		}
+	}
+	if err := (Inventory{Healthy: true}).Fits(100, 100, 100); err != nil {
+		t.Errorf("expected an unknown capacity to fit anything, got %v", err)
+	}
+	if err := (Inventory{Problems: []string{"docker is not running"}}).Fits(1, 0, 0); err == nil {
+		t.Error("expected nodes to not fit on an unhealthy server")
+	}
+}
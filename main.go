@@ -19,6 +19,7 @@
 package main
 
 import (
+	"github.com/whiteblock/genesis/inventory"
 	"github.com/whiteblock/genesis/rest"
 	"github.com/whiteblock/genesis/util"
 	"log"
@@ -30,5 +31,6 @@ func main() {
 	util.DisplayBanner()
 	conf = util.GetConfig()
 	log.SetFlags(log.LstdFlags | log.Llongfile)
+	go inventory.Monitor()
 	rest.StartServer()
 }
@@ -44,8 +44,14 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 	defer tn.FinishedBuilding()
+	return AddNodesToTestNet(details, tn)
+}
 
-	err = tn.AddDetails(*details)
+// AddNodesToTestNet adds nodes to the given testnet like AddNodes, but leaves the build in progress,
+// so that adding the nodes can be one step of a larger build.
+func AddNodesToTestNet(details *db.DeploymentDetails, tn *testnet.TestNet) error {
+	buildState := tn.BuildState
+	err := tn.AddDetails(*details)
 	if err != nil {
 		buildState.ReportError(err)
 		return err
@@ -32,15 +32,20 @@ func DelNodes(num int, testnetID string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	defer tn.FinishedBuilding()
+	return DelNodesFromTestNet(num, tn)
+}
+
+// DelNodesFromTestNet removes nodes from the given testnet like DelNodes, but leaves the build in
+// progress, so that removing the nodes can be one step of a larger build.
+func DelNodesFromTestNet(num int, tn *testnet.TestNet) error {
 	if num >= len(tn.Nodes) {
 		return fmt.Errorf("can't remove more than all the nodes in the network")
 	}
-	defer tn.FinishedBuilding()
-
 	for i := len(tn.Nodes) - 1; i >= (len(tn.Nodes) - num); i-- {
 		node := tn.Nodes[i]
 		client := tn.Clients[node.GetServerID()]
-		err = docker.Kill(client, node.GetRelativeNumber())
+		err := docker.Kill(client, node.GetRelativeNumber())
 		if err != nil {
 			return util.LogError(err)
 		}
@@ -48,6 +53,7 @@ func DelNodes(num int, testnetID string) error {
 		if err != nil {
 			return util.LogError(err)
 		}
+		tn.ClearNodeState(node.AbsoluteNum)
 	}
 	tn.Nodes = tn.Nodes[:(len(tn.Nodes) - num)]
 	return nil
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/archive"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/deploy"
 	"github.com/whiteblock/genesis/protocols/helpers"
@@ -78,6 +79,15 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 	}
 	buildState := tn.BuildState
 	defer tn.FinishedBuilding()
+	if conf.ArchiveLogsOnFailure {
+		//Runs before FinishedBuilding, so the nodes are still around when the build fails
+		defer func() {
+			if buildState.ErrorFree() || len(tn.Nodes) == 0 {
+				return
+			}
+			archive.Create(tn, "build failure: "+buildState.GetError().Error())
+		}()
+	}
 
 	//STEP 0: VALIDATE
 	err = validate(details)
@@ -107,6 +117,12 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 	}
 	log.WithFields(log.Fields{"build": testnetID}).Trace("Built the docker containers")
 
+	err = deploy.ApplyTopology(tn)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+
 	buildFn, err := registrar.GetBuildFunc(details.Blockchain)
 	if err != nil {
 		buildState.ReportError(err)
@@ -217,7 +233,12 @@ func DeleteTestNet(testnetID string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
-
+	if conf.ArchiveLogsOnDelete {
+		_, err = archive.Create(tn, "testnet deletion")
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to archive the logs before deletion")
+		}
+	}
 	return deploy.Destroy(tn)
 }
 
@@ -21,7 +21,10 @@ package manager
 import (
 	"fmt"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/inventory"
 	"github.com/whiteblock/genesis/util"
+	"strconv"
 )
 
 func validateResources(details *db.DeploymentDetails) error {
@@ -64,6 +67,56 @@ func validateBlockchain(details *db.DeploymentDetails) error {
 	return nil
 }
 
+func validateTopology(details *db.DeploymentDetails) error {
+	_, _, err := deploy.GetTopology(details)
+	return err
+}
+
+func validatePlacement(details *db.DeploymentDetails) error {
+	_, err := deploy.GetPlacement(details)
+	return err
+}
+
+// validateCapacity checks that the nodes fit on the servers they would be placed on, and that those servers
+// are healthy, according to the last probe of each server. Servers which have not been probed are not checked.
+func validateCapacity(details *db.DeploymentDetails) error {
+	plan, err := deploy.Plan(details, nil)
+	if err != nil {
+		return util.LogError(err)
+	}
+	nodes := map[int]int{}
+	cpus := map[int]float64{}
+	memory := map[int]int64{}
+	for _, node := range plan.Nodes {
+		nodes[node.Server]++
+		if !node.Resources.NoCPULimits() {
+			nodeCpus, err := strconv.ParseFloat(node.Resources.Cpus, 64)
+			if err != nil {
+				return fmt.Errorf("invalid cpus for node %d", node.AbsoluteNum)
+			}
+			cpus[node.Server] += nodeCpus
+		}
+		if !node.Resources.NoMemoryLimits() {
+			nodeMemory, err := node.Resources.GetMemory()
+			if err != nil {
+				return fmt.Errorf("invalid memory for node %d", node.AbsoluteNum)
+			}
+			memory[node.Server] += nodeMemory
+		}
+	}
+	for _, server := range plan.Servers {
+		inv, ok := inventory.Get(server.ID)
+		if !ok {
+			continue
+		}
+		err = inv.Fits(nodes[server.ID], cpus[server.ID], memory[server.ID])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func checkForNilOrMissing(details *db.DeploymentDetails) error {
 	if details.Servers == nil {
 		return fmt.Errorf("servers cannot be null")
@@ -109,5 +162,20 @@ func validate(details *db.DeploymentDetails) error {
 		return util.LogError(err)
 	}
 
+	err = validateTopology(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validatePlacement(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateCapacity(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
 	return validateBlockchain(details)
 }
@@ -252,6 +252,42 @@ func Test_validateBlockchain(t *testing.T) {
 	}
 }
 
+func Test_validateTopology(t *testing.T) {
+	var test = []struct {
+		extras  map[string]interface{}
+		isValid bool
+	}{
+		{extras: map[string]interface{}{}, isValid: true},
+		{extras: map[string]interface{}{"topology": "us-eu-asia"}, isValid: true},
+		{extras: map[string]interface{}{"topology": "atlantis"}, isValid: false},
+		{
+			extras: map[string]interface{}{"topology": map[string]interface{}{
+				"preset": "us-eu-asia", "regions": []interface{}{"us-east", "ap-northeast"}}},
+			isValid: true,
+		},
+		{
+			extras: map[string]interface{}{"topology": map[string]interface{}{
+				"preset": "us-eu-asia", "regions": []interface{}{"us-east"}}},
+			isValid: false,
+		},
+		{
+			extras: map[string]interface{}{"topology": map[string]interface{}{
+				"preset": "us-eu-asia", "regions": []interface{}{"us-east", "mars"}}},
+			isValid: false,
+		},
+		{extras: map[string]interface{}{"topology": 5}, isValid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateTopology(&db.DeploymentDetails{Nodes: 2, Extras: tt.extras})
+			if (err == nil) != tt.isValid {
+				t.Errorf("unexpected result from validateTopology: %v", err)
+			}
+		})
+	}
+}
+
 func Test_checkForNilOrMissing(t *testing.T) {
 	var test = []struct {
 		details  *db.DeploymentDetails
@@ -0,0 +1,44 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/util"
+)
+
+// PlanTestNet validates the given deployment details and works out how AddTestNet would build them,
+// without touching any of the servers
+func PlanTestNet(details *db.DeploymentDetails) (deploy.BuildPlan, error) {
+	if details.Servers == nil || len(details.Servers) == 0 {
+		return deploy.BuildPlan{}, fmt.Errorf("missing servers")
+	}
+	err := validate(details)
+	if err != nil {
+		return deploy.BuildPlan{}, util.LogError(err)
+	}
+	servicesFn, err := registrar.GetServiceFunc(details.Blockchain)
+	if err != nil {
+		return deploy.BuildPlan{}, util.LogError(err)
+	}
+	return deploy.Plan(details, servicesFn())
+}
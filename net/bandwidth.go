@@ -0,0 +1,215 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	//defaultLatency is the longest a packet can wait for tokens before being dropped, when not given
+	defaultLatency = "50ms"
+	//minBurst is the smallest default burst in bytes, enough for a few full sized packets
+	minBurst = 32 * 1024
+)
+
+var (
+	rateRegex    = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(bit|kbit|mbit|gbit|tbit|bps|kbps|mbps|gbps|tbps)$`)
+	burstRegex   = regexp.MustCompile(`^[0-9]+(b|k|kb|m|mb|g|gb|kbit|mbit|gbit)?$`)
+	latencyRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(us|ms|s)$`)
+
+	rateUnits = map[string]float64{
+		"bit":  1,
+		"kbit": 1e3,
+		"mbit": 1e6,
+		"gbit": 1e9,
+		"tbit": 1e12,
+		"bps":  8,
+		"kbps": 8e3,
+		"mbps": 8e6,
+		"gbps": 8e9,
+		"tbps": 8e12,
+	}
+)
+
+//Bandwidth is a token bucket limit on the traffic going in and out of a node.
+//Ingress is the traffic the node receives, and is shaped on the node's bridge,
+//Egress is the traffic the node sends, which is redirected through an ifb device to be shaped.
+type Bandwidth struct {
+	//Preset is the name of a bandwidth preset, which fills in the fields which are not given
+	Preset string `json:"preset,omitempty"`
+	//Ingress is the rate the node can receive at, ie "20mbit"
+	Ingress string `json:"ingress,omitempty"`
+	//Egress is the rate the node can send at, ie "3mbit"
+	Egress string `json:"egress,omitempty"`
+	//Burst is the size of the token bucket, ie "32kb"
+	Burst string `json:"burst,omitempty"`
+	//Latency is the longest a packet can wait for tokens before it is dropped, ie "50ms"
+	Latency string `json:"latency,omitempty"`
+}
+
+var bandwidthPresets = map[string]Bandwidth{
+	"home-dsl": {
+		Preset:  "home-dsl",
+		Ingress: "24mbit",
+		Egress:  "3mbit",
+		Burst:   "32kb",
+		Latency: "50ms",
+	},
+	"mobile-4g": {
+		Preset:  "mobile-4g",
+		Ingress: "20mbit",
+		Egress:  "8mbit",
+		Burst:   "32kb",
+		Latency: "100ms",
+	},
+	"datacenter-10g": {
+		Preset:  "datacenter-10g",
+		Ingress: "10gbit",
+		Egress:  "10gbit",
+		Burst:   "12mb",
+		Latency: "10ms",
+	},
+}
+
+//GetBandwidthPresets gets all of the bandwidth presets, by name
+func GetBandwidthPresets() map[string]Bandwidth {
+	out := map[string]Bandwidth{}
+	for name, preset := range bandwidthPresets {
+		out[name] = preset
+	}
+	return out
+}
+
+//ParseRate parses a tc rate, such as "10mbit", into bits per second. Like tc, the units are case insensitive.
+func ParseRate(rate string) (float64, error) {
+	matches := rateRegex.FindStringSubmatch(strings.ToLower(rate))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid rate \"%s\"", rate)
+	}
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return val * rateUnits[matches[2]], nil
+}
+
+//resolve fills in the fields which are not given from the preset
+func (bw Bandwidth) resolve() Bandwidth {
+	preset, ok := bandwidthPresets[bw.Preset]
+	if !ok {
+		return bw
+	}
+	if len(bw.Ingress) == 0 {
+		bw.Ingress = preset.Ingress
+	}
+	if len(bw.Egress) == 0 {
+		bw.Egress = preset.Egress
+	}
+	if len(bw.Burst) == 0 {
+		bw.Burst = preset.Burst
+	}
+	if len(bw.Latency) == 0 {
+		bw.Latency = preset.Latency
+	}
+	return bw
+}
+
+//Validate checks that the bandwidth limits are valid
+func (bw Bandwidth) Validate() error {
+	if len(bw.Preset) > 0 {
+		if _, ok := bandwidthPresets[bw.Preset]; !ok {
+			return fmt.Errorf("unknown bandwidth preset \"%s\"", bw.Preset)
+		}
+	}
+	bw = bw.resolve()
+	if len(bw.Ingress) == 0 && len(bw.Egress) == 0 {
+		return fmt.Errorf("bandwidth must limit either the ingress or the egress")
+	}
+	for _, rate := range []string{bw.Ingress, bw.Egress} {
+		if len(rate) == 0 {
+			continue
+		}
+		if _, err := ParseRate(rate); err != nil {
+			return err
+		}
+	}
+	if len(bw.Burst) > 0 && !burstRegex.MatchString(bw.Burst) {
+		return fmt.Errorf("invalid burst \"%s\"", bw.Burst)
+	}
+	if len(bw.Latency) > 0 && !latencyRegex.MatchString(bw.Latency) {
+		return fmt.Errorf("invalid latency \"%s\"", bw.Latency)
+	}
+	return nil
+}
+
+//tbfOptions generates the options for a tbf qdisc limiting to the given rate.
+//When the burst is not given, the bucket holds 10ms worth of traffic.
+func (bw Bandwidth) tbfOptions(rate string) string {
+	burst := bw.Burst
+	if len(burst) == 0 {
+		bits, _ := ParseRate(rate)
+		bytes := int(bits / 8 / 100)
+		if bytes < minBurst {
+			bytes = minBurst
+		}
+		burst = fmt.Sprintf("%db", bytes)
+	}
+	latency := bw.Latency
+	if len(latency) == 0 {
+		latency = defaultLatency
+	}
+	return fmt.Sprintf("tbf rate %s burst %s latency %s", rate, burst, latency)
+}
+
+//createClearBandwidthCommands generates the commands which remove the egress shaping from a node.
+//The ingress shaping is removed along with the root qdisc of the bridge.
+func createClearBandwidthCommands(node int) []string {
+	return []string{
+		fmt.Sprintf("sudo -n tc qdisc del dev %s%d ingress 2>/dev/null || true", conf.BridgePrefix, node),
+		fmt.Sprintf("sudo -n ip link del %s%d 2>/dev/null || true", conf.IFBPrefix, node),
+	}
+}
+
+//createBandwidthCommands generates the commands needed to shape the bandwidth of the node given
+//in netconf. Expects the netem qdisc created by CreateCommands to already be in place.
+func createBandwidthCommands(netconf Netconf) []string {
+	bw := netconf.Bandwidth.resolve()
+	bridge := fmt.Sprintf("%s%d", conf.BridgePrefix, netconf.Node)
+	ifb := fmt.Sprintf("%s%d", conf.IFBPrefix, netconf.Node)
+
+	out := createClearBandwidthCommands(netconf.Node)
+	if len(bw.Ingress) > 0 {
+		out = append(out, fmt.Sprintf("sudo -n tc qdisc add dev %s parent 2:1 handle 3: %s", bridge, bw.tbfOptions(bw.Ingress)))
+	}
+	if len(bw.Egress) > 0 {
+		out = append(out,
+			"sudo -n modprobe ifb numifbs=0",
+			fmt.Sprintf("sudo -n ip link add %s type ifb", ifb),
+			fmt.Sprintf("sudo -n ip link set dev %s up", ifb),
+			fmt.Sprintf("sudo -n tc qdisc add dev %s handle ffff: ingress", bridge),
+			fmt.Sprintf("sudo -n tc filter add dev %s parent ffff: protocol ip u32 match u32 0 0 action mirred egress redirect dev %s", bridge, ifb),
+			fmt.Sprintf("sudo -n tc qdisc add dev %s root handle 1: %s", ifb, bw.tbfOptions(bw.Egress)),
+		)
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	var test = []struct {
+		rate     string
+		expected float64
+		err      bool
+	}{
+		{rate: "100bit", expected: 100},
+		{rate: "24mbit", expected: 24e6},
+		{rate: "1.5gbit", expected: 1.5e9},
+		{rate: "2kbps", expected: 16e3},
+		{rate: "10", err: true},
+		{rate: "mbit", err: true},
+		{rate: "10 mbit", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error result for \"%s\": %v", tt.rate, err)
+			}
+			if rate != tt.expected {
+				t.Errorf("expected %f, got %f", tt.expected, rate)
+			}
+		})
+	}
+}
+
+func TestBandwidth_Validate(t *testing.T) {
+	var test = []struct {
+		bw  Bandwidth
+		err bool
+	}{
+		{bw: Bandwidth{Preset: "home-dsl"}},
+		{bw: Bandwidth{Preset: "mobile-4g", Egress: "1mbit"}},
+		{bw: Bandwidth{Ingress: "10mbit"}},
+		{bw: Bandwidth{Egress: "10mbit", Burst: "64kb", Latency: "20ms"}},
+		{bw: Bandwidth{}, err: true},
+		{bw: Bandwidth{Preset: "dialup"}, err: true},
+		{bw: Bandwidth{Ingress: "fast"}, err: true},
+		{bw: Bandwidth{Ingress: "10mbit", Burst: "lots"}, err: true},
+		{bw: Bandwidth{Ingress: "10mbit", Latency: "20"}, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.bw.Validate()
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error result for %+v: %v", tt.bw, err)
+			}
+		})
+	}
+}
+
+func TestCreateCommands_Bandwidth(t *testing.T) {
+	var test = []struct {
+		netconf  Netconf
+		expected []string
+	}{
+		{
+			netconf: Netconf{Node: 1, Delay: 100, Bandwidth: &Bandwidth{Ingress: "100mbit"}},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge1 root",
+				"sudo -n tc qdisc add dev wb_bridge1 root handle 1: prio",
+				"sudo -n tc qdisc add dev wb_bridge1 parent 1:1 handle 2: netem delay 100us",
+				"sudo -n tc filter add dev wb_bridge1 parent 1:0 protocol ip pref 55 handle 6 fw flowid 2:1",
+				"sudo -n iptables -t mangle -A PREROUTING  ! -d 10.1.0.17 -j MARK --set-mark 6",
+				"sudo -n tc qdisc del dev wb_bridge1 ingress 2>/dev/null || true",
+				"sudo -n ip link del wb_ifb1 2>/dev/null || true",
+				"sudo -n tc qdisc add dev wb_bridge1 parent 2:1 handle 3: tbf rate 100mbit burst 125000b latency 50ms",
+			},
+		},
+		{
+			netconf: Netconf{Node: 0, Bandwidth: &Bandwidth{Preset: "home-dsl", Latency: "20ms"}},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge0 root",
+				"sudo -n tc qdisc add dev wb_bridge0 root handle 1: prio",
+				"sudo -n tc qdisc add dev wb_bridge0 parent 1:1 handle 2: netem",
+				"sudo -n tc filter add dev wb_bridge0 parent 1:0 protocol ip pref 55 handle 6 fw flowid 2:1",
+				"sudo -n iptables -t mangle -A PREROUTING  ! -d 10.1.0.1 -j MARK --set-mark 6",
+				"sudo -n tc qdisc del dev wb_bridge0 ingress 2>/dev/null || true",
+				"sudo -n ip link del wb_ifb0 2>/dev/null || true",
+				"sudo -n tc qdisc add dev wb_bridge0 parent 2:1 handle 3: tbf rate 24mbit burst 32kb latency 20ms",
+				"sudo -n modprobe ifb numifbs=0",
+				"sudo -n ip link add wb_ifb0 type ifb",
+				"sudo -n ip link set dev wb_ifb0 up",
+				"sudo -n tc qdisc add dev wb_bridge0 handle ffff: ingress",
+				"sudo -n tc filter add dev wb_bridge0 parent ffff: protocol ip u32 match u32 0 0 action mirred egress redirect dev wb_ifb0",
+				"sudo -n tc qdisc add dev wb_ifb0 root handle 1: tbf rate 3mbit burst 32kb latency 20ms",
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cmds := CreateCommands(tt.netconf, 1)
+			if !reflect.DeepEqual(cmds, tt.expected) {
+				t.Errorf("return value of CreateCommands does not match expected value:\n%v", cmds)
+			}
+		})
+	}
+}
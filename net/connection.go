@@ -55,6 +55,25 @@ func (mesh *Connections) RemoveAll(conns []Connection) {
 	}
 }
 
+//FilterOneWay gets the connections which are only cut in one direction, from
+//the given cut connections
+func FilterOneWay(conns []Connection) []Connection {
+	out := []Connection{}
+	for _, conn := range conns {
+		reversed := false
+		for _, other := range conns {
+			if other.From == conn.To && other.To == conn.From {
+				reversed = true
+				break
+			}
+		}
+		if !reversed {
+			out = append(out, conn)
+		}
+	}
+	return out
+}
+
 func findPossiblePeers(cons [][]bool, node int) []int {
 	var out []int
 
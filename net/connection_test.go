@@ -160,3 +160,35 @@ func Test_Networks(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterOneWay(t *testing.T) {
+	var test = []struct {
+		conns    []Connection
+		expected []Connection
+	}{
+		{[]Connection{{To: 1, From: 0}, {To: 0, From: 1}}, []Connection{}},
+		{[]Connection{{To: 1, From: 0}, {To: 0, From: 2}}, []Connection{{To: 1, From: 0}, {To: 0, From: 2}}},
+		{[]Connection{{To: 1, From: 0}, {To: 2, From: 1}, {To: 1, From: 2}}, []Connection{{To: 1, From: 0}}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(FilterOneWay(tt.conns), tt.expected) {
+				t.Errorf("return value of FilterOneWay does not match expected value")
+			}
+		})
+	}
+}
+
+func Test_NetworksOneWay(t *testing.T) {
+	mesh := NewConnections(3)
+	mesh.RemoveAll([]Connection{{To: 2, From: 0}, {To: 2, From: 1}})
+	if !reflect.DeepEqual(mesh.Networks(), [][]int{{0, 1, 2}}) {
+		t.Errorf("one way outages should not partition the network")
+	}
+
+	mesh.RemoveAll([]Connection{{To: 0, From: 2}, {To: 1, From: 2}})
+	if !reflect.DeepEqual(mesh.Networks(), [][]int{{0, 1}, {2}}) {
+		t.Errorf("outages in both directions should partition the network")
+	}
+}
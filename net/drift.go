@@ -0,0 +1,423 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//defaultLimit is the packet limit netem uses when none is given
+const defaultLimit = 1000
+
+//netIntent is the network conditions genesis has applied to the nodes of a testnet
+type netIntent struct {
+	//Netconfs are the network conditions on each node, by absolute node number
+	Netconfs map[int]Netconf `json:"netconfs"`
+	//Links are the nodes with per link conditions, which are not checked for drift
+	Links map[int]bool `json:"links"`
+}
+
+//NodeState is the network conditions on a node, as read back from its server
+type NodeState struct {
+	Node    int      `json:"node"`
+	Netconf *Netconf `json:"netconf"`
+	//Marked is whether the iptables rule which marks the node's traffic for netem is in place
+	Marked bool `json:"marked"`
+}
+
+//Drift is a difference between the network conditions genesis applied to a node,
+//and the ones actually on it
+type Drift struct {
+	Node        int      `json:"node"`
+	Intended    *Netconf `json:"intended"`
+	Actual      *Netconf `json:"actual"`
+	Differences []string `json:"differences"`
+}
+
+var intentMux = sync.Mutex{}
+
+func intentKey(testnetID string) string {
+	return "netconf_" + testnetID
+}
+
+func getIntent(testnetID string) netIntent {
+	out := netIntent{}
+	err := db.GetMetaP(intentKey(testnetID), &out)
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": testnetID}).Debug("no stored network conditions")
+	}
+	if out.Netconfs == nil {
+		out.Netconfs = map[int]Netconf{}
+	}
+	if out.Links == nil {
+		out.Links = map[int]bool{}
+	}
+	return out
+}
+
+//recordIntent updates the stored network conditions of the testnets of the given nodes
+func recordIntent(nodes []db.Node, update func(intent *netIntent, node db.Node)) {
+	intentMux.Lock()
+	defer intentMux.Unlock()
+	intents := map[string]*netIntent{}
+	for _, node := range nodes {
+		intent, ok := intents[node.TestNetID]
+		if !ok {
+			loaded := getIntent(node.TestNetID)
+			intent = &loaded
+			intents[node.TestNetID] = intent
+		}
+		delete(intent.Netconfs, node.AbsoluteNum)
+		delete(intent.Links, node.AbsoluteNum)
+		update(intent, node)
+	}
+	for testnetID, intent := range intents {
+		err := db.SetMeta(intentKey(testnetID), *intent)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to store the network conditions")
+		}
+	}
+}
+
+func recordNetconf(nodes []db.Node, netconf Netconf) {
+	recordIntent(nodes, func(intent *netIntent, node db.Node) {
+		nconf := netconf
+		nconf.Node = node.LocalID
+		intent.Netconfs[node.AbsoluteNum] = nconf
+	})
+}
+
+func recordRemoval(nodes []db.Node) {
+	recordIntent(nodes, func(intent *netIntent, node db.Node) {})
+}
+
+func recordLinks(nodes []db.Node) {
+	recordIntent(nodes, func(intent *netIntent, node db.Node) {
+		intent.Links[node.AbsoluteNum] = true
+	})
+}
+
+//GetIntent gets the network conditions genesis has applied to each node of the testnet, by absolute node number
+func GetIntent(testnetID string) map[int]Netconf {
+	intentMux.Lock()
+	defer intentMux.Unlock()
+	return getIntent(testnetID).Netconfs
+}
+
+//GetLinkedNodes gets the absolute numbers of the nodes of the testnet which genesis has applied per link
+//conditions to
+func GetLinkedNodes(testnetID string) map[int]bool {
+	intentMux.Lock()
+	defer intentMux.Unlock()
+	return getIntent(testnetID).Links
+}
+
+//getBandwidthOnServer reads back the bandwidth limits on the server, by local node number
+func getBandwidthOnServer(client ssh.Client) (map[int]*Bandwidth, error) {
+	res, err := client.Run("sudo -n tc qdisc show | grep tbf || true")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := map[int]*Bandwidth{}
+	for _, line := range strings.Split(res, "\n") {
+		var dev, rate string
+		items := strings.Fields(line)
+		for i := 0; i+1 < len(items); i++ {
+			switch items[i] {
+			case "dev":
+				dev = items[i+1]
+			case "rate":
+				rate = items[i+1]
+			}
+		}
+		if len(dev) == 0 || len(rate) == 0 {
+			continue
+		}
+		var prefix string
+		switch {
+		case strings.HasPrefix(dev, conf.BridgePrefix):
+			prefix = conf.BridgePrefix
+		case strings.HasPrefix(dev, conf.IFBPrefix):
+			prefix = conf.IFBPrefix
+		default:
+			continue
+		}
+		num, err := strconv.Atoi(dev[len(prefix):])
+		if err != nil {
+			continue
+		}
+		if _, ok := out[num]; !ok {
+			out[num] = &Bandwidth{}
+		}
+		if prefix == conf.BridgePrefix {
+			out[num].Ingress = rate
+		} else {
+			out[num].Egress = rate
+		}
+	}
+	return out, nil
+}
+
+//GetStateOnServer reads back the network conditions on the given nodes on the given server,
+//by absolute node number. Nodes with no network conditions are included with a nil Netconf.
+func GetStateOnServer(client ssh.Client, serverID int, nodes []db.Node) (map[int]NodeState, error) {
+	out := map[int]NodeState{}
+	for _, node := range nodes {
+		if node.Server == serverID {
+			out[node.AbsoluteNum] = NodeState{Node: node.AbsoluteNum}
+		}
+	}
+
+	netconfs, err := GetConfigOnServer(client)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	bandwidths, err := getBandwidthOnServer(client)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	rules, err := client.Run("sudo -n iptables -t mangle -S PREROUTING || true")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+
+	for i := range netconfs {
+		node, err := db.GetNodeByServerAndLocalID(nodes, serverID, netconfs[i].Node)
+		if err != nil {
+			continue //Not a part of this testnet
+		}
+		state := out[node.AbsoluteNum]
+		state.Netconf = &netconfs[i]
+		state.Netconf.Bandwidth = bandwidths[node.LocalID]
+		state.Marked = strings.Contains(rules,
+			fmt.Sprintf("! -d %s/32 -j MARK", util.GetGateway(serverID, node.LocalID)))
+		out[node.AbsoluteNum] = state
+	}
+	return out, nil
+}
+
+//GetState reads back the network conditions on all of the given nodes, by absolute node number
+func GetState(nodes []db.Node) (map[int]NodeState, error) {
+	out := map[int]NodeState{}
+	servers := map[int]bool{}
+	for _, node := range nodes {
+		servers[node.Server] = true
+	}
+	for serverID := range servers {
+		client, err := status.GetClient(serverID)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		states, err := GetStateOnServer(client, serverID, nodes)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		for num, state := range states {
+			out[num] = state
+		}
+	}
+	return out, nil
+}
+
+//timeTolerance is how far off a time in us can be after being printed by tc
+func timeTolerance(us int) float64 {
+	switch {
+	case us >= 1000000:
+		return 50000
+	case us >= 1000:
+		return 50
+	default:
+		return 0.5
+	}
+}
+
+//rateEqual checks if two rates given to tc are the same
+func rateEqual(r1 string, r2 string) bool {
+	if len(r1) == 0 || len(r2) == 0 {
+		return len(r1) == len(r2)
+	}
+	v1, err1 := ParseRate(r1)
+	v2, err2 := ParseRate(r2)
+	if err1 != nil || err2 != nil {
+		return r1 == r2
+	}
+	return math.Abs(v1-v2) <= 0.01*math.Max(v1, v2)
+}
+
+//CompareNetconf lists the differences between the intended network conditions and the ones read back
+//from tc. Values are compared to the precision tc reports them in, and the distribution is ignored
+//since tc does not report it.
+func CompareNetconf(intended Netconf, actual Netconf) []string {
+	out := []string{}
+	if intended.Limit == 0 {
+		intended.Limit = defaultLimit
+	}
+	if intended.Limit != actual.Limit {
+		out = append(out, fmt.Sprintf("limit is %d instead of %d", actual.Limit, intended.Limit))
+	}
+
+	times := []struct {
+		name     string
+		intended int
+		actual   int
+	}{
+		{"delay", intended.Delay, actual.Delay},
+		{"jitter", intended.Jitter, actual.Jitter},
+	}
+	for _, t := range times {
+		if math.Abs(float64(t.intended-t.actual)) > timeTolerance(t.intended) {
+			out = append(out, fmt.Sprintf("%s is %dus instead of %dus", t.name, t.actual, t.intended))
+		}
+	}
+
+	percents := []struct {
+		name     string
+		intended float64
+		actual   float64
+	}{
+		{"loss", intended.Loss, actual.Loss},
+		{"lossCorrelation", intended.LossCorrelation, actual.LossCorrelation},
+		{"delayCorrelation", intended.DelayCorrelation, actual.DelayCorrelation},
+		{"duplicate", intended.Duplication, actual.Duplication},
+		{"duplicateCorrelation", intended.DuplicationCorrelation, actual.DuplicationCorrelation},
+		{"corrupt", intended.Corrupt, actual.Corrupt},
+		{"corruptCorrelation", intended.CorruptCorrelation, actual.CorruptCorrelation},
+		{"reorder", intended.Reorder, actual.Reorder},
+		{"reorderCorrelation", intended.ReorderCorrelation, actual.ReorderCorrelation},
+	}
+	for _, p := range percents {
+		if math.Abs(p.intended-p.actual) > 0.01 {
+			out = append(out, fmt.Sprintf("%s is %g%% instead of %g%%", p.name, p.actual, p.intended))
+		}
+	}
+
+	if intended.Gap != actual.Gap {
+		out = append(out, fmt.Sprintf("gap is %d instead of %d", actual.Gap, intended.Gap))
+	}
+	if intended.ECN != actual.ECN {
+		out = append(out, fmt.Sprintf("ecn is %v instead of %v", actual.ECN, intended.ECN))
+	}
+	if !rateEqual(intended.Rate, actual.Rate) {
+		out = append(out, fmt.Sprintf("rate is \"%s\" instead of \"%s\"", actual.Rate, intended.Rate))
+	}
+	if (intended.GEModel == nil) != (actual.GEModel == nil) ||
+		(intended.GEModel != nil && (math.Abs(intended.GEModel.P-actual.GEModel.P) > 0.01 ||
+			math.Abs(intended.GEModel.R-actual.GEModel.R) > 0.01 ||
+			math.Abs(intended.GEModel.OneMinusH-actual.GEModel.OneMinusH) > 0.01 ||
+			math.Abs(intended.GEModel.OneMinusK-actual.GEModel.OneMinusK) > 0.01)) {
+		out = append(out, "gemodel does not match")
+	}
+
+	intendedBw := Bandwidth{}
+	if intended.Bandwidth != nil {
+		intendedBw = intended.Bandwidth.resolve()
+	}
+	actualBw := Bandwidth{}
+	if actual.Bandwidth != nil {
+		actualBw = *actual.Bandwidth
+	}
+	if !rateEqual(intendedBw.Ingress, actualBw.Ingress) {
+		out = append(out, fmt.Sprintf("ingress bandwidth is \"%s\" instead of \"%s\"", actualBw.Ingress, intendedBw.Ingress))
+	}
+	if !rateEqual(intendedBw.Egress, actualBw.Egress) {
+		out = append(out, fmt.Sprintf("egress bandwidth is \"%s\" instead of \"%s\"", actualBw.Egress, intendedBw.Egress))
+	}
+	return out
+}
+
+//calculateDrift compares the intended network conditions against the state read back from the servers
+func calculateDrift(intent netIntent, states map[int]NodeState) []Drift {
+	out := []Drift{}
+	for num, state := range states {
+		if intent.Links[num] {
+			continue
+		}
+		drift := Drift{Node: num, Actual: state.Netconf, Differences: []string{}}
+		if intended, ok := intent.Netconfs[num]; ok {
+			drift.Intended = &intended
+		}
+		switch {
+		case drift.Intended == nil && drift.Actual == nil:
+			continue
+		case drift.Intended == nil:
+			drift.Differences = append(drift.Differences, "network conditions are applied to the node")
+		case drift.Actual == nil:
+			drift.Differences = append(drift.Differences, "network conditions are missing from the node")
+		default:
+			drift.Differences = CompareNetconf(*drift.Intended, *drift.Actual)
+			if !state.Marked {
+				drift.Differences = append(drift.Differences, "iptables mark rule is missing")
+			}
+		}
+		if len(drift.Differences) > 0 {
+			out = append(out, drift)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Node < out[j].Node })
+	return out
+}
+
+//CheckDrift compares the network conditions genesis has applied to the given nodes of a testnet
+//against the ones actually on the servers. Nodes with per link conditions are not checked.
+func CheckDrift(testnetID string, nodes []db.Node) ([]Drift, error) {
+	states, err := GetState(nodes)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	intentMux.Lock()
+	intent := getIntent(testnetID)
+	intentMux.Unlock()
+	return calculateDrift(intent, states), nil
+}
+
+//Reconcile re-applies the network conditions genesis has applied to each node which has
+//drifted from them, returning the drift which was corrected
+func Reconcile(testnetID string, nodes []db.Node) ([]Drift, error) {
+	drifts, err := CheckDrift(testnetID, nodes)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	for _, drift := range drifts {
+		node, err := db.GetNodeByAbsNum(nodes, drift.Node)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		log.WithFields(log.Fields{"testnet": testnetID, "node": drift.Node,
+			"differences": drift.Differences}).Info("reconciling the network conditions")
+		if drift.Intended == nil {
+			err = RemoveAll([]db.Node{node})
+		} else {
+			err = ApplyToAll(*drift.Intended, []db.Node{node})
+		}
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+	}
+	return drifts, nil
+}
@@ -0,0 +1,137 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func TestGetStateOnServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	nodes := []db.Node{
+		{AbsoluteNum: 0, Server: 1, LocalID: 0},
+		{AbsoluteNum: 1, Server: 1, LocalID: 1},
+		{AbsoluteNum: 2, Server: 2, LocalID: 0},
+		{AbsoluteNum: 3, Server: 1, LocalID: 2},
+	}
+
+	client.EXPECT().Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").Return(
+		"qdisc netem 2: dev wb_bridge0 parent 1:1 limit 1000 delay 100.0ms  10.0ms\n"+
+			"qdisc netem 2: dev wb_bridge1 parent 1:1 limit 1000 loss 5%\n"+
+			"qdisc netem 2: dev wb_bridge7 parent 1:1 limit 1000 loss 5%\n", nil)
+	client.EXPECT().Run("sudo -n tc qdisc show | grep tbf || true").Return(
+		"qdisc tbf 3: dev wb_bridge1 parent 2:1 rate 24Mbit burst 32Kb lat 50.0ms\n"+
+			"qdisc tbf 1: dev wb_ifb1 root refcnt 2 rate 3Mbit burst 32Kb lat 50.0ms\n", nil)
+	client.EXPECT().Run("sudo -n iptables -t mangle -S PREROUTING || true").Return(
+		"-P PREROUTING ACCEPT\n-A PREROUTING ! -d 10.1.0.1/32 -j MARK --set-xmark 0x6/0xffffffff\n", nil)
+
+	expected := map[int]NodeState{
+		0: {Node: 0, Netconf: &Netconf{Node: 0, Limit: 1000, Delay: 100000, Jitter: 10000}, Marked: true},
+		1: {Node: 1, Netconf: &Netconf{Node: 1, Limit: 1000, Loss: 5,
+			Bandwidth: &Bandwidth{Ingress: "24Mbit", Egress: "3Mbit"}}},
+		3: {Node: 3},
+	}
+
+	states, err := GetStateOnServer(client, 1, nodes)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("return value of GetStateOnServer does not match expected value")
+	}
+}
+
+func TestCompareNetconf(t *testing.T) {
+	var test = []struct {
+		intended    Netconf
+		actual      Netconf
+		differences int
+	}{
+		{
+			intended: Netconf{Delay: 1234, Jitter: 100, Loss: 0.06, Distribution: "normal"},
+			actual:   Netconf{Limit: 1000, Delay: 1200, Jitter: 100, Loss: 0.06},
+		},
+		{
+			intended: Netconf{Limit: 10, Rate: "10mbit", Bandwidth: &Bandwidth{Preset: "home-dsl"}},
+			actual:   Netconf{Limit: 10, Rate: "10Mbit", Bandwidth: &Bandwidth{Ingress: "24Mbit", Egress: "3Mbit"}},
+		},
+		{
+			intended:    Netconf{Delay: 100000, Bandwidth: &Bandwidth{Egress: "3mbit"}},
+			actual:      Netconf{Limit: 1000, Delay: 50000},
+			differences: 2,
+		},
+		{
+			intended:    Netconf{Loss: 1, Reorder: 5},
+			actual:      Netconf{Limit: 500, GEModel: &GEModel{P: 1}},
+			differences: 4,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			differences := CompareNetconf(tt.intended, tt.actual)
+			if len(differences) != tt.differences {
+				t.Errorf("expected %d differences, got %v", tt.differences, differences)
+			}
+		})
+	}
+}
+
+func Test_calculateDrift(t *testing.T) {
+	intent := netIntent{
+		Netconfs: map[int]Netconf{
+			0: {Node: 0, Delay: 1000},
+			1: {Node: 1, Delay: 1000},
+			2: {Node: 0, Loss: 5},
+		},
+		Links: map[int]bool{4: true},
+	}
+	states := map[int]NodeState{
+		0: {Node: 0, Netconf: &Netconf{Node: 0, Limit: 1000, Delay: 1000}, Marked: true},
+		1: {Node: 1, Netconf: &Netconf{Node: 1, Limit: 1000, Delay: 1000}},
+		2: {Node: 2},
+		3: {Node: 3, Netconf: &Netconf{Node: 1, Limit: 1000, Loss: 1}, Marked: true},
+		4: {Node: 4, Netconf: &Netconf{Node: 2, Limit: 1000, Loss: 1}, Marked: true},
+		5: {Node: 5},
+	}
+
+	drifts := calculateDrift(intent, states)
+	nums := []int{}
+	for _, drift := range drifts {
+		nums = append(nums, drift.Node)
+	}
+	if !reflect.DeepEqual(nums, []int{1, 2, 3}) {
+		t.Errorf("expected drift on nodes [1 2 3], got %v", nums)
+	}
+	if drifts[1].Actual != nil || drifts[1].Intended == nil {
+		t.Errorf("node 2 should be missing its network conditions")
+	}
+	if drifts[2].Intended != nil || drifts[2].Actual == nil {
+		t.Errorf("node 3 should have unexpected network conditions")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+)
+
+//linkClassRate is the rate given to each htb class, the actual rate limiting is left to netem
+const linkClassRate = "10gbit"
+
+//impaired checks if the link has any impairments on it
+func (link Link) impaired() bool {
+	return link.Loss > 0 || link.Delay > 0 || len(link.Rate) > 0 || link.Duplication > 0 ||
+		link.Corrupt > 0 || link.Reorder > 0
+}
+
+//netconf converts the link into the equivalent netconf for the ingress node
+func (link Link) netconf() Netconf {
+	return Netconf{
+		Node:        link.IngressNode,
+		Loss:        link.Loss,
+		Delay:       link.Delay,
+		Rate:        link.Rate,
+		Duplication: link.Duplication,
+		Corrupt:     link.Corrupt,
+		Reorder:     link.Reorder,
+	}
+}
+
+// CreateLinkCommands generates the commands needed to apply the given incoming links
+// on the bridge of node. Each link is given its own htb class with a netem qdisc, and the traffic
+// is sorted into that class by the ip addresses of the two ends of the link.
+func CreateLinkCommands(node db.Node, links []Link, nodes []db.Node) ([]string, error) {
+	bridge := fmt.Sprintf("%s%d", conf.BridgePrefix, node.LocalID)
+	out := []string{
+		fmt.Sprintf("sudo -n tc qdisc del dev %s root", bridge),
+		fmt.Sprintf("sudo -n tc qdisc add dev %s root handle 1: htb", bridge),
+	}
+	class := 0
+	for _, link := range links {
+		if link.EgressNode == link.IngressNode || !link.impaired() {
+			continue
+		}
+		if link.IngressNode != node.AbsoluteNum {
+			return nil, fmt.Errorf("link %d->%d does not go to node %d", link.EgressNode, link.IngressNode, node.AbsoluteNum)
+		}
+		peer, err := db.GetNodeByAbsNum(nodes, link.EgressNode)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		class++
+		out = append(out,
+			fmt.Sprintf("sudo -n tc class add dev %s parent 1: classid 1:%x htb rate %s", bridge, class, linkClassRate),
+			fmt.Sprintf("sudo -n tc qdisc add dev %s parent 1:%x handle %x: netem%s", bridge, class, class+1,
+				netemOptions(link.netconf())),
+			fmt.Sprintf("sudo -n tc filter add dev %s parent 1: protocol ip prio 1 u32 match ip src %s/32 match ip dst %s/32 flowid 1:%x",
+				bridge, peer.IP, node.IP, class),
+		)
+	}
+	return out, nil
+}
+
+// runCommands runs the given tc commands in order, ignoring the result of the first, which
+// clears the existing configuration
+func runCommands(client ssh.Client, cmds []string) error {
+	for i, cmd := range cmds {
+		_, err := client.Run(cmd)
+		if i == 0 {
+			continue
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"cmd": cmd, "error": err}).Error("error running tc command")
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+//ApplyLinks applies the given link matrix to the nodes, where links[i][j] is the link from the
+//node with the absolute number i to the node with the absolute number j
+func ApplyLinks(links [][]Link, nodes []db.Node) error {
+	if len(links) != len(nodes) {
+		return fmt.Errorf("expected a %dx%d link matrix, got %d rows", len(nodes), len(nodes), len(links))
+	}
+	for i := range links {
+		if len(links[i]) != len(nodes) {
+			return fmt.Errorf("expected %d links in row %d, got %d", len(nodes), i, len(links[i]))
+		}
+	}
+	for _, node := range nodes {
+		if node.AbsoluteNum >= len(links) {
+			return fmt.Errorf("node %d is outside of the link matrix", node.AbsoluteNum)
+		}
+		incoming := make([]Link, len(links))
+		for i := range links {
+			incoming[i] = links[i][node.AbsoluteNum]
+			incoming[i].EgressNode = i
+			incoming[i].IngressNode = node.AbsoluteNum
+		}
+		cmds, err := CreateLinkCommands(node, incoming, nodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = runCommands(client, cmds)
+		if err != nil {
+			return util.LogError(err)
+		}
+		recordLinks([]db.Node{node})
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func TestCreateLinkCommands(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, LocalID: 0, Server: 1, IP: "10.1.0.2"},
+		{AbsoluteNum: 1, LocalID: 1, Server: 1, IP: "10.1.0.6"},
+		{AbsoluteNum: 2, LocalID: 0, Server: 2, IP: "10.2.0.2"},
+	}
+
+	var test = []struct {
+		node     db.Node
+		links    []Link
+		expected []string
+	}{
+		{
+			node: nodes[0],
+			links: []Link{
+				{EgressNode: 0, IngressNode: 0, Delay: 5},
+				{EgressNode: 1, IngressNode: 0, Delay: 1000, Loss: 0.5},
+				{EgressNode: 2, IngressNode: 0, Delay: 2000, Rate: "10mbit"},
+			},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge0 root",
+				"sudo -n tc qdisc add dev wb_bridge0 root handle 1: htb",
+				"sudo -n tc class add dev wb_bridge0 parent 1: classid 1:1 htb rate 10gbit",
+				"sudo -n tc qdisc add dev wb_bridge0 parent 1:1 handle 2: netem loss 0.5000 delay 1000us",
+				"sudo -n tc filter add dev wb_bridge0 parent 1: protocol ip prio 1 u32 match ip src 10.1.0.6/32 match ip dst 10.1.0.2/32 flowid 1:1",
+				"sudo -n tc class add dev wb_bridge0 parent 1: classid 1:2 htb rate 10gbit",
+				"sudo -n tc qdisc add dev wb_bridge0 parent 1:2 handle 3: netem delay 2000us rate 10mbit",
+				"sudo -n tc filter add dev wb_bridge0 parent 1: protocol ip prio 1 u32 match ip src 10.2.0.2/32 match ip dst 10.1.0.2/32 flowid 1:2",
+			},
+		},
+		{
+			node: nodes[2],
+			links: []Link{
+				{EgressNode: 0, IngressNode: 2},
+				{EgressNode: 1, IngressNode: 2, Corrupt: 0.1},
+				{EgressNode: 2, IngressNode: 2},
+			},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge0 root",
+				"sudo -n tc qdisc add dev wb_bridge0 root handle 1: htb",
+				"sudo -n tc class add dev wb_bridge0 parent 1: classid 1:1 htb rate 10gbit",
+				"sudo -n tc qdisc add dev wb_bridge0 parent 1:1 handle 2: netem corrupt 0.1000",
+				"sudo -n tc filter add dev wb_bridge0 parent 1: protocol ip prio 1 u32 match ip src 10.1.0.6/32 match ip dst 10.2.0.2/32 flowid 1:1",
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cmds, err := CreateLinkCommands(tt.node, tt.links, nodes)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(cmds, tt.expected) {
+				t.Errorf("return value of CreateLinkCommands does not match expected value")
+			}
+		})
+	}
+}
+
+func TestCreateLinkCommands_WrongNode(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 0, IP: "10.1.0.2"}, {AbsoluteNum: 1, LocalID: 1, IP: "10.1.0.6"}}
+	_, err := CreateLinkCommands(nodes[0], []Link{{EgressNode: 0, IngressNode: 1, Delay: 10}}, nodes)
+	if err == nil {
+		t.Errorf("CreateLinkCommands should reject links which do not go to the given node")
+	}
+}
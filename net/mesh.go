@@ -20,7 +20,6 @@ package netconf
 
 import (
 	"github.com/whiteblock/genesis/util"
-	"math"
 )
 
 //Calculator contains functions used to calculate network impairments based on a distance
@@ -45,38 +44,28 @@ type Link struct {
 	Reorder     float64 `json:"reorder"`
 }
 
-//GetDefaultCalculator creates a calculator which can be used to calculate latency
-func GetDefaultCalculator() *Calculator { //TODO: improve the equations
+//GetDefaultCalculator creates a calculator which can be used to calculate latency, treating
+//the distance between points as kilometers. Like the topology presets, it only adds delay,
+//distance alone does not make a link lossy.
+func GetDefaultCalculator() *Calculator {
 	return &Calculator{
 		Loss: func(dist float64) float64 {
-			if dist == 0 {
-				return 0
-			}
-			return math.Min(math.Log(dist), 100.0)
+			return 0
 		},
 		Delay: func(dist float64) int {
-			return int(dist * 10)
+			return int(EstimateRTT(dist) * 1000 / 2)
 		},
 		Rate: func(dist float64) string {
 			return ""
 		},
 		Duplication: func(dist float64) float64 {
-			if dist == 0 {
-				return 0
-			}
-			return math.Min(math.Log10(dist), 100.0)
+			return 0
 		},
 		Corrupt: func(dist float64) float64 {
-			if dist == 0 {
-				return 0
-			}
-			return math.Min(math.Log10(dist), 100.0)
+			return 0
 		},
 		Reorder: func(dist float64) float64 {
-			if dist == 0 {
-				return 0
-			}
-			return math.Min(math.Log10(dist), 100.0)
+			return 0
 		},
 	}
 }
@@ -72,3 +72,14 @@ func TestCreateLinks(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDefaultCalculator(t *testing.T) {
+	links := CreateLinks([]util.Point{{X: 0, Y: 0}, {X: 5570, Y: 0}}, nil)
+	expected := Link{EgressNode: 0, IngressNode: 1, Delay: int(EstimateRTT(5570) * 1000 / 2)}
+	if !reflect.DeepEqual(links[0][1], expected) {
+		t.Errorf("expected only delay on the link, got %+v", links[0][1])
+	}
+	if links[1][0].Delay != links[0][1].Delay {
+		t.Errorf("expected the links to be symmetric, got %d and %d", links[0][1].Delay, links[1][0].Delay)
+	}
+}
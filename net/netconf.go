@@ -26,6 +26,7 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -47,16 +48,144 @@ import (
 
 var conf = util.GetConfig()
 
-//Netconf is a representation of the impairments applied to a node
+//timeRegex matches a time given by tc, ie "415.9s"
+var timeRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(s|ms|us|ns)$`)
+
+//distributions are the delay distribution tables which ship with tc
+var distributions = map[string]bool{"uniform": true, "normal": true, "pareto": true, "paretonormal": true}
+
+//netemKeywords are the options which start a new item in the output of tc
+var netemKeywords = map[string]bool{
+	"limit":        true,
+	"delay":        true,
+	"distribution": true,
+	"loss":         true,
+	"ecn":          true,
+	"rate":         true,
+	"duplicate":    true,
+	"corrupt":      true,
+	"reorder":      true,
+	"gap":          true,
+	"slot":         true,
+	"seed":         true,
+}
+
+//GEModel is the Gilbert-Elliott loss model, where packets are lost according to
+//whether the link is in a good or a bad state. All values are percentages.
+type GEModel struct {
+	//P is the chance of moving to the bad state
+	P float64 `json:"p"`
+	//R is the chance of moving back to the good state
+	R float64 `json:"r"`
+	//OneMinusH is the chance of losing a packet in the bad state
+	OneMinusH float64 `json:"1-h"`
+	//OneMinusK is the chance of losing a packet in the good state
+	OneMinusK float64 `json:"1-k"`
+}
+
+//Netconf is a representation of the impairments applied to a node. Delay and Jitter are in
+//microseconds, the rest of the values are percentages, ie 100% = 100.
 type Netconf struct {
-	Node        int     `json:"node"`
-	Limit       int     `json:"limit"`
-	Loss        float64 `json:"loss"` //Loss % ie 100% = 100
-	Delay       int     `json:"delay"`
-	Rate        string  `json:"rate"`
-	Duplication float64 `json:"duplicate"`
-	Corrupt     float64 `json:"corrupt"`
-	Reorder     float64 `json:"reorder"`
+	Node             int     `json:"node"`
+	Limit            int     `json:"limit"`
+	Loss             float64 `json:"loss"` //Loss % ie 100% = 100
+	LossCorrelation  float64 `json:"lossCorrelation,omitempty"`
+	Delay            int     `json:"delay"`
+	Jitter           int     `json:"jitter,omitempty"`
+	DelayCorrelation float64 `json:"delayCorrelation,omitempty"`
+	//Distribution is the distribution of the jitter, one of uniform, normal, pareto and paretonormal.
+	//tc does not report it back, so it is lost when reading the config from a server.
+	Distribution           string   `json:"distribution,omitempty"`
+	Rate                   string   `json:"rate"`
+	Duplication            float64  `json:"duplicate"`
+	DuplicationCorrelation float64  `json:"duplicateCorrelation,omitempty"`
+	Corrupt                float64  `json:"corrupt"`
+	CorruptCorrelation     float64  `json:"corruptCorrelation,omitempty"`
+	Reorder                float64  `json:"reorder"`
+	ReorderCorrelation     float64  `json:"reorderCorrelation,omitempty"`
+	Gap                    int      `json:"gap,omitempty"`
+	GEModel                *GEModel `json:"gemodel,omitempty"`
+	//ECN marks packets with congestion experienced instead of dropping them
+	ECN bool `json:"ecn,omitempty"`
+	//Bandwidth shapes the traffic in each direction with a token bucket, unlike Rate which only delays
+	//the traffic going to the node
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
+}
+
+//Validate checks that the network conditions are within their bounds
+func (netconf Netconf) Validate() error {
+	percents := []struct {
+		name  string
+		value float64
+		//base is the value the percent depends on, the percent must be 0 unless base is set
+		base bool
+	}{
+		{"loss", netconf.Loss, true},
+		{"lossCorrelation", netconf.LossCorrelation, netconf.Loss > 0},
+		{"delayCorrelation", netconf.DelayCorrelation, netconf.Jitter > 0},
+		{"duplicate", netconf.Duplication, true},
+		{"duplicateCorrelation", netconf.DuplicationCorrelation, netconf.Duplication > 0},
+		{"corrupt", netconf.Corrupt, true},
+		{"corruptCorrelation", netconf.CorruptCorrelation, netconf.Corrupt > 0},
+		{"reorder", netconf.Reorder, true},
+		{"reorderCorrelation", netconf.ReorderCorrelation, netconf.Reorder > 0},
+	}
+	if netconf.GEModel != nil {
+		percents = append(percents, []struct {
+			name  string
+			value float64
+			base  bool
+		}{
+			{"gemodel.p", netconf.GEModel.P, true},
+			{"gemodel.r", netconf.GEModel.R, true},
+			{"gemodel.1-h", netconf.GEModel.OneMinusH, true},
+			{"gemodel.1-k", netconf.GEModel.OneMinusK, true},
+		}...)
+	}
+	for _, percent := range percents {
+		if percent.value < 0 || percent.value > 100 {
+			return fmt.Errorf("%s must be between 0 and 100", percent.name)
+		}
+		if percent.value > 0 && !percent.base {
+			return fmt.Errorf("%s has no effect without the value it correlates", percent.name)
+		}
+	}
+	if netconf.Limit < 0 {
+		return fmt.Errorf("limit cannot be negative")
+	}
+	if netconf.Delay < 0 {
+		return fmt.Errorf("delay cannot be negative")
+	}
+	if netconf.Jitter < 0 {
+		return fmt.Errorf("jitter cannot be negative")
+	}
+	if netconf.Jitter > 0 && netconf.Delay == 0 {
+		return fmt.Errorf("jitter requires a delay")
+	}
+	if len(netconf.Distribution) > 0 {
+		if !distributions[netconf.Distribution] {
+			return fmt.Errorf("unknown distribution \"%s\"", netconf.Distribution)
+		}
+		if netconf.Jitter == 0 {
+			return fmt.Errorf("distribution requires jitter")
+		}
+	}
+	if netconf.GEModel != nil && netconf.Loss > 0 {
+		return fmt.Errorf("loss and gemodel cannot be used together")
+	}
+	if netconf.ECN && netconf.Loss == 0 && netconf.GEModel == nil {
+		return fmt.Errorf("ecn requires loss")
+	}
+	if netconf.Gap < 0 {
+		return fmt.Errorf("gap cannot be negative")
+	}
+	if netconf.Gap > 0 && (netconf.Reorder == 0 || netconf.Delay == 0) {
+		return fmt.Errorf("gap requires reorder and delay")
+	}
+	if netconf.Bandwidth != nil {
+		return netconf.Bandwidth.Validate()
+	}
+	return nil
 }
 
 // CreateCommands generates the commands needed to obtain the desired
@@ -73,34 +202,77 @@ func CreateCommands(netconf Netconf, serverID int) []string {
 			util.GetGateway(serverID, netconf.Node), offset),
 	}
 
+	out[2] += netemOptions(netconf)
+
+	if netconf.Bandwidth != nil {
+		out = append(out, createBandwidthCommands(netconf)...)
+	}
+	return out
+}
+
+//netemOptions generates the options given to the netem qdisc for netconf
+func netemOptions(netconf Netconf) string {
+	out := ""
 	if netconf.Limit > 0 {
-		out[2] += fmt.Sprintf(" limit %d", netconf.Limit)
+		out += fmt.Sprintf(" limit %d", netconf.Limit)
 	}
 
 	if netconf.Loss > 0 {
-		out[2] += fmt.Sprintf(" loss %.4f", netconf.Loss)
+		out += fmt.Sprintf(" loss %.4f", netconf.Loss)
+		if netconf.LossCorrelation > 0 {
+			out += fmt.Sprintf(" %.4f", netconf.LossCorrelation)
+		}
+	}
+
+	if netconf.GEModel != nil {
+		out += fmt.Sprintf(" loss gemodel %.4f %.4f %.4f %.4f", netconf.GEModel.P, netconf.GEModel.R,
+			netconf.GEModel.OneMinusH, netconf.GEModel.OneMinusK)
+	}
+
+	if netconf.ECN {
+		out += " ecn"
 	}
 
 	if netconf.Delay > 0 {
-		out[2] += fmt.Sprintf(" delay %dus", netconf.Delay)
+		out += fmt.Sprintf(" delay %dus", netconf.Delay)
+		if netconf.Jitter > 0 {
+			out += fmt.Sprintf(" %dus", netconf.Jitter)
+			if netconf.DelayCorrelation > 0 {
+				out += fmt.Sprintf(" %.4f", netconf.DelayCorrelation)
+			}
+			if len(netconf.Distribution) > 0 {
+				out += fmt.Sprintf(" distribution %s", netconf.Distribution)
+			}
+		}
 	}
 
 	if len(netconf.Rate) > 0 {
-		out[2] += fmt.Sprintf(" rate %s", netconf.Rate)
+		out += fmt.Sprintf(" rate %s", netconf.Rate)
 	}
 
 	if netconf.Duplication > 0 {
-		out[2] += fmt.Sprintf(" duplicate %.4f", netconf.Duplication)
+		out += fmt.Sprintf(" duplicate %.4f", netconf.Duplication)
+		if netconf.DuplicationCorrelation > 0 {
+			out += fmt.Sprintf(" %.4f", netconf.DuplicationCorrelation)
+		}
 	}
 
 	if netconf.Corrupt > 0 {
-		out[2] += fmt.Sprintf(" corrupt %.4f", netconf.Duplication)
+		out += fmt.Sprintf(" corrupt %.4f", netconf.Corrupt)
+		if netconf.CorruptCorrelation > 0 {
+			out += fmt.Sprintf(" %.4f", netconf.CorruptCorrelation)
+		}
 	}
 
 	if netconf.Reorder > 0 {
-		out[2] += fmt.Sprintf(" reorder %.4f", netconf.Reorder)
+		out += fmt.Sprintf(" reorder %.4f", netconf.Reorder)
+		if netconf.ReorderCorrelation > 0 {
+			out += fmt.Sprintf(" %.4f", netconf.ReorderCorrelation)
+		}
+		if netconf.Gap > 0 {
+			out += fmt.Sprintf(" gap %d", netconf.Gap)
+		}
 	}
-
 	return out
 }
 
@@ -135,6 +307,7 @@ func ApplyAll(netconfs []Netconf, nodes []db.Node) error {
 		if err != nil {
 			return util.LogError(err)
 		}
+		recordNetconf([]db.Node{node}, netconf)
 	}
 	return nil
 }
@@ -159,6 +332,7 @@ func ApplyToAll(netconf Netconf, nodes []db.Node) error {
 				return util.LogError(err)
 			}
 		}
+		recordNetconf([]db.Node{node}, netconf)
 	}
 	return nil
 }
@@ -175,7 +349,14 @@ func RemoveAll(nodes []db.Node) error {
 		if err != nil {
 			log.Error(err)
 		}
+		for _, cmd := range createClearBandwidthCommands(node.LocalID) {
+			_, err = client.Run(cmd)
+			if err != nil {
+				log.Error(err)
+			}
+		}
 	}
+	recordRemoval(nodes)
 	return nil
 }
 
@@ -188,62 +369,159 @@ func RemoveAllOnServer(client ssh.Client, nodes int) {
 	RemoveAllOutages(client)
 }
 
-func parseItems(items []string, nconf *Netconf) error {
+//parsePercent parses a percentage given by tc, ie "0.5%"
+func parsePercent(raw string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+}
 
-	for i := 0; i < len(items)/2; i++ {
-		switch items[2*i] {
-		case "limit":
-			val, err := strconv.Atoi(items[2*i+1])
+//parseTime parses a time given by tc, ie "415.9s", into microseconds
+func parseTime(raw string) (int, error) {
+	matches := timeRegex.FindStringSubmatch(raw)
+	if matches == nil {
+		return 0, fmt.Errorf("unexpected time value \"%s\"", raw)
+	}
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	switch matches[2] {
+	case "s":
+		val *= 1000000
+	case "ms":
+		val *= 1000
+	case "ns":
+		val /= 1000
+	}
+	return int(math.Round(val)), nil
+}
+
+//parseItems parses the options of a netem qdisc, as printed by tc, into nconf. Options
+//which take optional arguments consume them until the next option.
+func parseItems(items []string, nconf *Netconf) error {
+	tokens := []string{}
+	for _, item := range items {
+		if len(item) > 0 {
+			tokens = append(tokens, item)
+		}
+	}
+	i := 0
+	//arg consumes the next token if it is an argument of the current option
+	arg := func() (string, bool) {
+		if i+1 >= len(tokens) || netemKeywords[tokens[i+1]] {
+			return "", false
+		}
+		i++
+		return tokens[i], true
+	}
+	//optional consumes the next token if it is a numeric argument of the current option
+	optional := func() (string, bool) {
+		if i+1 >= len(tokens) || len(tokens[i+1]) == 0 || tokens[i+1][0] < '0' || tokens[i+1][0] > '9' {
+			return "", false
+		}
+		return arg()
+	}
+	//percentArgs consumes a percentage and its optional correlation
+	percentArgs := func(option string, percent *float64, correlation *float64) error {
+		raw, ok := arg()
+		if !ok {
+			return fmt.Errorf("%s is missing its value", option)
+		}
+		val, err := parsePercent(raw)
+		if err != nil {
+			return util.LogError(err)
+		}
+		*percent = val
+		if raw, ok = optional(); ok {
+			val, err = parsePercent(raw)
 			if err != nil {
 				return util.LogError(err)
 			}
-			nconf.Limit = val
-		case "loss":
-			val, err := strconv.ParseFloat(items[2*i+1][:len(items[2*i+1])-1], 64)
-			if err != nil {
-				return util.LogError(err)
+			*correlation = val
+		}
+		return nil
+	}
+
+	for ; i < len(tokens); i++ {
+		var err error
+		switch tokens[i] {
+		case "limit":
+			raw, ok := arg()
+			if !ok {
+				return fmt.Errorf("limit is missing its value")
 			}
-			nconf.Loss = val
+			nconf.Limit, err = strconv.Atoi(raw)
 		case "delay":
-			re := regexp.MustCompile(`(?m)[0-9]+\.[0-9]+`)
-			matches := re.FindAllString(items[2*i+1], -1)
-			if len(matches) == 0 {
-				return fmt.Errorf("unexpected delay value \"%s\"", items[2*i+1])
+			raw, ok := arg()
+			if !ok {
+				return fmt.Errorf("delay is missing its value")
 			}
-
-			val, err := strconv.ParseFloat(matches[0], 64)
+			nconf.Delay, err = parseTime(raw)
 			if err != nil {
 				return util.LogError(err)
 			}
-			unit := items[2*i+1][len(matches[0]):]
-			switch unit {
-			case "s":
-				val *= 1000
-				fallthrough
-			case "ms":
-				val *= 1000
+			if raw, ok = optional(); ok {
+				nconf.Jitter, err = parseTime(raw)
+				if err != nil {
+					return util.LogError(err)
+				}
+			}
+			if raw, ok = optional(); ok {
+				nconf.DelayCorrelation, err = parsePercent(raw)
 			}
-			nconf.Delay = int(val)
+		case "distribution":
+			raw, ok := arg()
+			if !ok {
+				return fmt.Errorf("distribution is missing its value")
+			}
+			nconf.Distribution = raw
+		case "loss":
+			if i+1 < len(tokens) && tokens[i+1] == "gemodel" {
+				i++
+				values := []float64{}
+				for raw, ok := arg(); ok; raw, ok = arg() {
+					switch raw {
+					case "p", "r", "1-h", "1-k":
+						continue
+					}
+					val, err := parsePercent(raw)
+					if err != nil {
+						return util.LogError(err)
+					}
+					values = append(values, val)
+				}
+				if len(values) != 4 {
+					return fmt.Errorf("expected 4 values for gemodel, got %d", len(values))
+				}
+				nconf.GEModel = &GEModel{P: values[0], R: values[1], OneMinusH: values[2], OneMinusK: values[3]}
+				continue
+			}
+			if i+1 < len(tokens) && tokens[i+1] == "random" {
+				i++
+			}
+			err = percentArgs("loss", &nconf.Loss, &nconf.LossCorrelation)
+		case "ecn":
+			nconf.ECN = true
 		case "rate":
-			nconf.Rate = items[2*i+1]
-		case "duplicate":
-			val, err := strconv.ParseFloat(items[2*i+1][:len(items[2*i+1])-1], 64)
-			if err != nil {
-				return util.LogError(err)
+			raw, ok := arg()
+			if !ok {
+				return fmt.Errorf("rate is missing its value")
 			}
-			nconf.Duplication = val
+			nconf.Rate = raw
+		case "duplicate":
+			err = percentArgs("duplicate", &nconf.Duplication, &nconf.DuplicationCorrelation)
 		case "corrupt":
-			val, err := strconv.ParseFloat(items[2*i+1][:len(items[2*i+1])-1], 64)
-			if err != nil {
-				return util.LogError(err)
-			}
-			nconf.Corrupt = val
+			err = percentArgs("corrupt", &nconf.Corrupt, &nconf.CorruptCorrelation)
 		case "reorder":
-			val, err := strconv.ParseFloat(items[2*i+1][:len(items[2*i+1])-1], 64)
-			if err != nil {
-				return util.LogError(err)
+			err = percentArgs("reorder", &nconf.Reorder, &nconf.ReorderCorrelation)
+		case "gap":
+			raw, ok := arg()
+			if !ok {
+				return fmt.Errorf("gap is missing its value")
 			}
-			nconf.Reorder = val
+			nconf.Gap, err = strconv.Atoi(raw)
+		}
+		if err != nil {
+			return util.LogError(err)
 		}
 	}
 	return nil
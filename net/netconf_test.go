@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -217,3 +218,89 @@ func TestGetConfigOnServer_Unsuccessful2(t *testing.T) {
 		t.Errorf("return value of GetConfigOnServer does not match expected value")
 	}
 }
+
+func Test_parseItems_Extended(t *testing.T) {
+	var test = []struct {
+		raw      string
+		expected Netconf
+	}{
+		{
+			raw:      "limit 1000 delay 100.0ms  10.0ms 25% loss 1% 50% duplicate 2% corrupt 0.1% 5% reorder 25% 50% gap 5",
+			expected: Netconf{Limit: 1000, Delay: 100000, Jitter: 10000, DelayCorrelation: 25, Loss: 1, LossCorrelation: 50, Duplication: 2, Corrupt: 0.1, CorruptCorrelation: 5, Reorder: 25, ReorderCorrelation: 50, Gap: 5},
+		},
+		{
+			raw:      "limit 1000 loss gemodel p 1% r 10% 1-h 100% 1-k 0% ecn  rate 10Mbit",
+			expected: Netconf{Limit: 1000, GEModel: &GEModel{P: 1, R: 10, OneMinusH: 100, OneMinusK: 0}, ECN: true, Rate: "10Mbit"},
+		},
+		{
+			raw:      "limit 1000 delay 1s 250us",
+			expected: Netconf{Limit: 1000, Delay: 1000000, Jitter: 250},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var nconf Netconf
+			err := parseItems(strings.Split(tt.raw, " "), &nconf)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(nconf, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, nconf)
+			}
+		})
+	}
+}
+
+func Test_parseItems_RoundTrip(t *testing.T) {
+	var test = []Netconf{
+		{Limit: 100, Loss: 2.5, LossCorrelation: 25, Delay: 50000, Jitter: 5000, DelayCorrelation: 10,
+			Distribution: "normal", Rate: "1mbit", Duplication: 1, DuplicationCorrelation: 2,
+			Corrupt: 0.5, CorruptCorrelation: 1, Reorder: 10, ReorderCorrelation: 20, Gap: 3},
+		{Delay: 10, GEModel: &GEModel{P: 0.5, R: 20, OneMinusH: 70, OneMinusK: 0.1}, ECN: true},
+	}
+
+	for i, nconf := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if err := nconf.Validate(); err != nil {
+				t.Error(err)
+			}
+			var parsed Netconf
+			err := parseItems(strings.Split(netemOptions(nconf), " "), &parsed)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(parsed, nconf) {
+				t.Errorf("expected %+v, got %+v", nconf, parsed)
+			}
+		})
+	}
+}
+
+func TestNetconf_Validate(t *testing.T) {
+	var test = []struct {
+		nconf Netconf
+		err   bool
+	}{
+		{nconf: Netconf{Loss: 1, LossCorrelation: 25, Delay: 100, Jitter: 10, Distribution: "pareto"}},
+		{nconf: Netconf{Loss: 101}, err: true},
+		{nconf: Netconf{LossCorrelation: 25}, err: true},
+		{nconf: Netconf{Jitter: 10}, err: true},
+		{nconf: Netconf{Delay: 100, DelayCorrelation: 25}, err: true},
+		{nconf: Netconf{Delay: 100, Jitter: 10, Distribution: "gaussian"}, err: true},
+		{nconf: Netconf{Loss: 1, GEModel: &GEModel{P: 1}}, err: true},
+		{nconf: Netconf{GEModel: &GEModel{P: 1, R: 120}}, err: true},
+		{nconf: Netconf{ECN: true}, err: true},
+		{nconf: Netconf{Reorder: 10, Gap: 5}, err: true},
+		{nconf: Netconf{Delay: 100, Reorder: 10, Gap: 5}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.nconf.Validate()
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error result for %+v: %v", tt.nconf, err)
+			}
+		})
+	}
+}
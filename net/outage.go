@@ -61,38 +61,29 @@ func RemoveAllOutages(client ssh.Client) error {
 	return nil
 }
 
-func makeOutageCommands(node1 db.Node, node2 db.Node) []string {
-	return []string{
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node1.AbsoluteNum, node2.IP),
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node2.AbsoluteNum, node1.IP),
-	}
+func makeOneWayOutageCommand(from db.Node, to db.Node) string {
+	return fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, from.LocalID, to.IP)
 }
 
-func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
+func mkrmOneWayOutage(from db.Node, to db.Node, create bool) error {
 	flag := "-I"
 	if !create {
 		flag = "-D"
 	}
-	cmds := makeOutageCommands(node1, node2)
-
-	client, err := status.GetClient(node1.Server)
-	if err != nil {
-		return util.LogError(err)
-	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[0]))
+	client, err := status.GetClient(from.Server)
 	if err != nil {
 		return util.LogError(err)
 	}
-	client, err = status.GetClient(node2.Server)
-	if err != nil {
-		return util.LogError(err)
-	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[1]))
+	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, makeOneWayOutageCommand(from, to)))
+	return util.LogError(err)
+}
+
+func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
+	err := mkrmOneWayOutage(node1, node2, create)
 	if err != nil {
 		return util.LogError(err)
 	}
-
-	return nil
+	return mkrmOneWayOutage(node2, node1, create)
 }
 
 //MakeOutage removes the ability for the given nodes to connect
@@ -105,27 +96,54 @@ func RemoveOutage(node1 db.Node, node2 db.Node) error {
 	return mkrmOutage(node1, node2, false)
 }
 
-//CreatePartitionOutage causes the two sides to be unable to communicate with one and the other
-func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) { //Doesn't report errors yet
+func mkrmPartitionOutage(side1 []db.Node, side2 []db.Node, create bool) error {
 	wg := sync.WaitGroup{}
+	errMux := sync.Mutex{}
+	var out error
 	for _, node1 := range side1 {
 		for _, node2 := range side2 {
 			wg.Add(1)
 			go func(node1 db.Node, node2 db.Node) {
 				defer wg.Done()
-				err := MakeOutage(node1, node2)
+				err := mkrmOutage(node1, node2, create)
 				if err != nil {
 					log.Error(err)
+					errMux.Lock()
+					out = err
+					errMux.Unlock()
 				}
 			}(node1, node2)
 		}
 	}
 	wg.Wait()
+	return out
+}
+
+//MakeOneWayOutage removes the ability for from to send to to, while still allowing
+//to to send to from
+func MakeOneWayOutage(from db.Node, to db.Node) error {
+	return mkrmOneWayOutage(from, to, true)
+}
+
+//RemoveOneWayOutage returns the ability for from to send to to
+func RemoveOneWayOutage(from db.Node, to db.Node) error {
+	return mkrmOneWayOutage(from, to, false)
 }
 
-//GetCutConnections fetches the cut connections on a server
-//TODO: Naive Implementation, does not yet take multiple servers into account
-func GetCutConnections(client ssh.Client) ([]Connection, error) {
+//CreatePartitionOutage causes the two sides to be unable to communicate with one and the other.
+//The rules which could be created are kept if some of them fail.
+func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) error {
+	return mkrmPartitionOutage(side1, side2, true)
+}
+
+//RemovePartitionOutage allows the two sides of a partition to communicate again
+func RemovePartitionOutage(side1 []db.Node, side2 []db.Node) error {
+	return mkrmPartitionOutage(side1, side2, false)
+}
+
+//GetCutConnections fetches the cut connections on the given server, resolved to the absolute
+//numbers of the given nodes. Rules which do not belong to any of the given nodes are ignored.
+func GetCutConnections(client ssh.Client, serverID int, nodes []db.Node) ([]Connection, error) {
 	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD | awk '{print $4,$6}' | sed -e 's/\\/32//g' || true")
 	if err != nil {
 		return nil, util.LogError(err)
@@ -145,40 +163,80 @@ func GetCutConnections(client ssh.Client) ([]Connection, error) {
 		if len(cutPair) != 2 {
 			return nil, fmt.Errorf("unexpected result \"%s\" for cut pair", cut)
 		}
-		_, toNode, _ := util.GetInfoFromIP(cutPair[0])
 
 		if len(cutPair[1]) <= len(conf.BridgePrefix) {
 			return nil, fmt.Errorf("unexpected source interface, found \"%s\"", cutPair[1])
 		}
 
-		fromNode, err := strconv.Atoi(cutPair[1][len(conf.BridgePrefix):])
+		localID, err := strconv.Atoi(cutPair[1][len(conf.BridgePrefix):])
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		fromNode, err := db.GetNodeByServerAndLocalID(nodes, serverID, localID)
+		if err != nil {
+			log.WithFields(log.Fields{"server": serverID, "rule": cut}).Debug("ignoring a rule for an unknown source")
+			continue
+		}
+		toNode, err := db.GetNodeByIP(nodes, cutPair[0])
+		if err != nil {
+			log.WithFields(log.Fields{"server": serverID, "rule": cut}).Debug("ignoring a rule for an unknown destination")
+			continue
+		}
+		out = append(out, Connection{To: toNode.AbsoluteNum, From: fromNode.AbsoluteNum})
+		log.WithFields(log.Fields{"to": toNode.AbsoluteNum, "from": fromNode.AbsoluteNum}).Debug("found a disconnection")
+	}
+	return out, nil
+}
+
+//GetAllCutConnections fetches the cut connections between the given nodes, across all of the
+//servers they are on
+func GetAllCutConnections(nodes []db.Node) ([]Connection, error) {
+	out := []Connection{}
+	for _, serverID := range db.GetUniqueServerIDs(nodes) {
+		client, err := status.GetClient(serverID)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		out = append(out, Connection{To: toNode, From: fromNode})
-		log.WithFields(log.Fields{"to": toNode, "from": fromNode}).Debug("found a disconnection")
+		conns, err := GetCutConnections(client, serverID, nodes)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, conns...)
 	}
 	return out, nil
 }
 
-//CalculatePartitions calculates the current partitions in the network
+//CalculatePartitions calculates the current partitions in the network. Nodes which
+//are only cut off in one direction are still able to communicate, so they are considered
+//to be in the same partition.
 func CalculatePartitions(nodes []db.Node) ([][]int, error) {
-	clients, err := status.GetClientsFromNodes(nodes)
+	cutConnections, err := GetAllCutConnections(nodes)
 	if err != nil {
 		return nil, util.LogError(err)
 	}
-	cutConnections := []Connection{}
-	for _, client := range clients {
-		conns, err := GetCutConnections(client)
-		if err != nil {
-			return nil, util.LogError(err)
-		}
-		cutConnections = append(cutConnections, conns...)
+	return calculatePartitions(nodes, cutConnections), nil
+}
+
+func calculatePartitions(nodes []db.Node, cutConnections []Connection) [][]int {
+	//The connection graph is indexed by the position of the node, as there may be gaps
+	//in the absolute numbers
+	indexes := map[int]int{}
+	for i, node := range nodes {
+		indexes[node.AbsoluteNum] = i
+	}
+	cuts := []Connection{}
+	for _, conn := range cutConnections {
+		cuts = append(cuts, Connection{To: indexes[conn.To], From: indexes[conn.From]})
 	}
 
 	conns := NewConnections(len(nodes))
+	conns.RemoveAll(cuts)
 
-	conns.RemoveAll(cutConnections)
-
-	return conns.Networks(), nil
+	out := conns.Networks()
+	for i := range out {
+		for j := range out[i] {
+			out[i][j] = nodes[out[i][j]].AbsoluteNum
+		}
+	}
+	return out
 }
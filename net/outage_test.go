@@ -19,9 +19,11 @@
 package netconf
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/ssh/mocks"
 )
 
@@ -35,3 +37,41 @@ func TestRemoveAllOutages(t *testing.T) {
 
 	RemoveAllOutages(client)
 }
+
+func TestGetCutConnections(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nodes := []db.Node{
+		{AbsoluteNum: 0, Server: 1, LocalID: 0, IP: "10.1.0.2"},
+		{AbsoluteNum: 1, Server: 2, LocalID: 0, IP: "10.2.0.2"},
+		{AbsoluteNum: 2, Server: 1, LocalID: 1, IP: "10.1.0.6"},
+	}
+	client := mocks.NewMockClient(ctrl)
+	client.
+		EXPECT().
+		Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD | awk '{print $4,$6}' | sed -e 's/\\/32//g' || true").
+		Return("10.2.0.2 wb_bridge0\n10.1.0.2 wb_bridge1\n10.5.0.2 wb_bridge1\n10.2.0.2 wb_bridge7\n", nil)
+
+	conns, err := GetCutConnections(client, 1, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Connection{{To: 1, From: 0}, {To: 0, From: 2}}
+	if !reflect.DeepEqual(conns, expected) {
+		t.Errorf("return value of GetCutConnections does not match expected value")
+	}
+}
+
+func Test_calculatePartitions(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, Server: 1, LocalID: 0},
+		{AbsoluteNum: 2, Server: 2, LocalID: 0},
+		{AbsoluteNum: 5, Server: 1, LocalID: 1},
+	}
+	cuts := []Connection{{To: 5, From: 0}, {To: 0, From: 5}, {To: 5, From: 2}, {To: 2, From: 5}}
+	expected := [][]int{{0, 2}, {5}}
+	if !reflect.DeepEqual(calculatePartitions(nodes, cuts), expected) {
+		t.Errorf("return value of calculatePartitions does not match expected value")
+	}
+}
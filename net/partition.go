@@ -0,0 +1,302 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//PartitionActive is the state of a timed partition while the network is split
+	PartitionActive = "partitioned"
+	//PartitionHealed is the state of a timed partition while it is healed between repeats
+	PartitionHealed = "healed"
+	//PartitionFinished is the state of a timed partition which has completed all of its repeats
+	PartitionFinished = "finished"
+	//PartitionCancelled is the state of a timed partition which was cancelled
+	PartitionCancelled = "cancelled"
+	//PartitionFailed is the state of a timed partition which stopped because the network could not be
+	//partitioned or healed
+	PartitionFailed = "failed"
+)
+
+//partitionRetention is how long a timed partition is kept around after it stops
+const partitionRetention = time.Hour
+
+//TimedPartition is a partition which heals itself after a duration, optionally
+//partitioning the network again after some time
+type TimedPartition struct {
+	ID        string `json:"id"`
+	TestnetID string `json:"testnetId"`
+	//Nodes are the absolute numbers of the nodes on one side of the partition
+	Nodes []int `json:"nodes"`
+	//Duration is the number of seconds the network stays partitioned
+	Duration int `json:"duration"`
+	//Heal is the number of seconds the network stays healed between repeats
+	Heal int `json:"heal"`
+	//Repeat is the number of times the network is partitioned
+	Repeat int `json:"repeat"`
+
+	State   string    `json:"state"`
+	Cycle   int       `json:"cycle"`
+	Started time.Time `json:"started"`
+	//Stopped is when the timed partition stopped, nil while it is running
+	Stopped *time.Time `json:"stopped,omitempty"`
+	//Error is why the timed partition failed
+	Error string `json:"error,omitempty"`
+}
+
+type partitionRunner struct {
+	partition  TimedPartition
+	kid        string
+	split      func() error
+	heal       func() error
+	cancel     chan struct{}
+	cancelOnce sync.Once
+	mux        sync.RWMutex
+}
+
+var (
+	timedPartitions = map[string]map[string]*partitionRunner{}
+	partitionMux    = sync.Mutex{}
+)
+
+//Validate checks that the timed partition is valid, and fills in the defaults
+func (tp *TimedPartition) Validate() error {
+	if len(tp.Nodes) == 0 {
+		return fmt.Errorf("partition must have at least one node")
+	}
+	if tp.Duration <= 0 {
+		return fmt.Errorf("duration must be greater than zero")
+	}
+	if tp.Heal < 0 {
+		return fmt.Errorf("heal cannot be negative")
+	}
+	if tp.Repeat < 0 {
+		return fmt.Errorf("repeat cannot be negative")
+	}
+	if tp.Repeat == 0 {
+		tp.Repeat = 1
+	}
+	return nil
+}
+
+func (pr *partitionRunner) setState(state string, cycle int) {
+	pr.mux.Lock()
+	defer pr.mux.Unlock()
+	pr.partition.State = state
+	pr.partition.Cycle = cycle
+}
+
+//stop sets the final state of the timed partition
+func (pr *partitionRunner) stop(state string, cycle int, err error) {
+	pr.mux.Lock()
+	defer pr.mux.Unlock()
+	pr.partition.State = state
+	pr.partition.Cycle = cycle
+	now := time.Now()
+	pr.partition.Stopped = &now
+	if err != nil {
+		pr.partition.Error = err.Error()
+	}
+}
+
+func (pr *partitionRunner) stopped() bool {
+	pr.mux.RLock()
+	defer pr.mux.RUnlock()
+	return pr.partition.Stopped != nil
+}
+
+//record adds the splitting or healing of the network to the event journal of the testnet
+func (pr *partitionRunner) record(action string, cycle int, err error) {
+	tp := pr.get()
+	details := map[string]interface{}{"id": tp.ID, "cycle": cycle}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	_, err = db.InsertEvent(db.Event{
+		TestNetID: tp.TestnetID,
+		Kid:       pr.kid,
+		Action:    action,
+		Nodes:     tp.Nodes,
+		Details:   details,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": tp.TestnetID, "partition": tp.ID, "error": err}).Error("failed to record an event")
+	}
+}
+
+//doSplit partitions the network, healing it again if that fails
+func (pr *partitionRunner) doSplit(cycle int) error {
+	err := pr.split()
+	pr.record("timed partition split", cycle, err)
+	if err != nil {
+		pr.heal()
+	}
+	return err
+}
+
+func (pr *partitionRunner) doHeal(cycle int) error {
+	err := pr.heal()
+	pr.record("timed partition heal", cycle, err)
+	return err
+}
+
+func (pr *partitionRunner) get() TimedPartition {
+	pr.mux.RLock()
+	defer pr.mux.RUnlock()
+	return pr.partition
+}
+
+//wait waits for the given number of seconds, returns false if cancelled in the meantime
+func (pr *partitionRunner) wait(seconds int) bool {
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	select {
+	case <-pr.cancel:
+		timer.Stop()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (pr *partitionRunner) run() {
+	tp := pr.get()
+	for cycle := 1; cycle <= tp.Repeat; cycle++ {
+		log.WithFields(log.Fields{"testnet": tp.TestnetID, "partition": tp.ID, "cycle": cycle}).Info("partitioning the network")
+		err := pr.doSplit(cycle)
+		if err != nil {
+			pr.stop(PartitionFailed, cycle, err)
+			return
+		}
+		pr.setState(PartitionActive, cycle)
+		ok := pr.wait(tp.Duration)
+		log.WithFields(log.Fields{"testnet": tp.TestnetID, "partition": tp.ID, "cycle": cycle}).Info("healing the network")
+		err = pr.doHeal(cycle)
+		if err != nil {
+			pr.stop(PartitionFailed, cycle, err)
+			return
+		}
+		if !ok {
+			pr.stop(PartitionCancelled, cycle, nil)
+			return
+		}
+		if cycle == tp.Repeat {
+			break
+		}
+		pr.setState(PartitionHealed, cycle)
+		if !pr.wait(tp.Heal) {
+			pr.stop(PartitionCancelled, cycle, nil)
+			return
+		}
+	}
+	pr.stop(PartitionFinished, tp.Repeat, nil)
+}
+
+//prunePartitions removes the timed partitions which stopped more than partitionRetention ago.
+//The caller must hold partitionMux.
+func prunePartitions() {
+	for testnetID, partitions := range timedPartitions {
+		for id, pr := range partitions {
+			tp := pr.get()
+			if tp.Stopped != nil && time.Since(*tp.Stopped) > partitionRetention {
+				delete(partitions, id)
+			}
+		}
+		if len(partitions) == 0 {
+			delete(timedPartitions, testnetID)
+		}
+	}
+}
+
+func startTimedPartition(tp TimedPartition, kid string, split func() error, heal func() error) (TimedPartition, error) {
+	err := tp.Validate()
+	if err != nil {
+		return tp, util.LogError(err)
+	}
+	tp.ID, err = util.GetUUIDString()
+	if err != nil {
+		return tp, util.LogError(err)
+	}
+	tp.Started = time.Now()
+	tp.State = PartitionActive
+	tp.Cycle = 1
+	tp.Stopped = nil
+	tp.Error = ""
+
+	pr := &partitionRunner{partition: tp, kid: kid, split: split, heal: heal, cancel: make(chan struct{})}
+
+	partitionMux.Lock()
+	defer partitionMux.Unlock()
+	prunePartitions()
+	if _, ok := timedPartitions[tp.TestnetID]; !ok {
+		timedPartitions[tp.TestnetID] = map[string]*partitionRunner{}
+	}
+	timedPartitions[tp.TestnetID][tp.ID] = pr
+	go pr.run()
+	return tp, nil
+}
+
+//StartTimedPartition partitions tp.Nodes from the rest of the given nodes in the background,
+//healing and repeating the partition according to tp. Each split and heal is recorded in the
+//event journal of the testnet, under the given kid.
+func StartTimedPartition(tp TimedPartition, nodes []db.Node, kid string) (TimedPartition, error) {
+	side1, side2, err := db.DivideNodesByAbsMatch(nodes, tp.Nodes)
+	if err != nil {
+		return tp, util.LogError(err)
+	}
+	return startTimedPartition(tp, kid,
+		func() error { return CreatePartitionOutage(side1, side2) },
+		func() error { return RemovePartitionOutage(side1, side2) })
+}
+
+//GetTimedPartitions gets all of the timed partitions on the given testnet, ordered by their start time.
+//Timed partitions are forgotten an hour after they stop.
+func GetTimedPartitions(testnetID string) []TimedPartition {
+	partitionMux.Lock()
+	defer partitionMux.Unlock()
+	prunePartitions()
+	out := []TimedPartition{}
+	for _, pr := range timedPartitions[testnetID] {
+		out = append(out, pr.get())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+//CancelTimedPartition stops a timed partition, healing the network if it is currently partitioned
+func CancelTimedPartition(testnetID string, id string) error {
+	partitionMux.Lock()
+	defer partitionMux.Unlock()
+	pr, ok := timedPartitions[testnetID][id]
+	if !ok {
+		return fmt.Errorf("timed partition %s not found", id)
+	}
+	if pr.stopped() {
+		return fmt.Errorf("timed partition %s has already stopped", id)
+	}
+	pr.cancelOnce.Do(func() { close(pr.cancel) })
+	return nil
+}
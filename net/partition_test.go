@@ -0,0 +1,142 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimedPartition_Validate(t *testing.T) {
+	var test = []struct {
+		tp      TimedPartition
+		isValid bool
+	}{
+		{tp: TimedPartition{Nodes: []int{0}, Duration: 10}, isValid: true},
+		{tp: TimedPartition{Nodes: []int{}, Duration: 10}, isValid: false},
+		{tp: TimedPartition{Nodes: []int{0}, Duration: 0}, isValid: false},
+		{tp: TimedPartition{Nodes: []int{0}, Duration: 10, Heal: -1}, isValid: false},
+		{tp: TimedPartition{Nodes: []int{0}, Duration: 10, Repeat: -2}, isValid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.tp.Validate()
+			if (err == nil) != tt.isValid {
+				t.Errorf("unexpected result from Validate: %v", err)
+			}
+			if err == nil && tt.tp.Repeat != 1 {
+				t.Errorf("expected repeat to default to 1, got %d", tt.tp.Repeat)
+			}
+		})
+	}
+}
+
+func TestCancelTimedPartition(t *testing.T) {
+	var splits, heals int32
+	tp, err := startTimedPartition(TimedPartition{TestnetID: "partition-test", Nodes: []int{0}, Duration: 1000, Repeat: 3}, "",
+		func() error { atomic.AddInt32(&splits, 1); return nil },
+		func() error { atomic.AddInt32(&heals, 1); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitions := GetTimedPartitions("partition-test")
+	if len(partitions) != 1 || partitions[0].ID != tp.ID {
+		t.Fatalf("expected to find the timed partition, got %+v", partitions)
+	}
+
+	err = CancelTimedPartition("partition-test", tp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && GetTimedPartitions("partition-test")[0].State != PartitionCancelled; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state := GetTimedPartitions("partition-test")[0].State; state != PartitionCancelled {
+		t.Fatalf("expected the partition to be cancelled, got %s", state)
+	}
+	if atomic.LoadInt32(&splits) != 1 || atomic.LoadInt32(&heals) != 1 {
+		t.Errorf("expected the network to be partitioned and healed once, got %d and %d", splits, heals)
+	}
+	err = CancelTimedPartition("partition-test", tp.ID)
+	if err == nil {
+		t.Errorf("should not be able to cancel a partition which has already stopped")
+	}
+	err = CancelTimedPartition("partition-test", "missing")
+	if err == nil {
+		t.Errorf("should not be able to cancel a partition which does not exist")
+	}
+}
+
+func TestTimedPartition_Failed(t *testing.T) {
+	var heals int32
+	tp, err := startTimedPartition(TimedPartition{TestnetID: "partition-fail-test", Nodes: []int{0}, Duration: 1000, Repeat: 3}, "",
+		func() error { return fmt.Errorf("split failed") },
+		func() error { atomic.AddInt32(&heals, 1); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && GetTimedPartitions("partition-fail-test")[0].Stopped == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	result := GetTimedPartitions("partition-fail-test")[0]
+	if result.ID != tp.ID || result.State != PartitionFailed {
+		t.Fatalf("expected the partition to fail, got %+v", result)
+	}
+	if result.Error != "split failed" {
+		t.Errorf("expected the error to be reported, got \"%s\"", result.Error)
+	}
+	if atomic.LoadInt32(&heals) != 1 {
+		t.Errorf("expected the network to be healed after failing to split it, got %d heals", heals)
+	}
+}
+
+func TestPrunePartitions(t *testing.T) {
+	stopped := time.Now().Add(-partitionRetention - time.Minute)
+	recent := time.Now()
+	partitionMux.Lock()
+	timedPartitions["prune-test"] = map[string]*partitionRunner{
+		"old":     {partition: TimedPartition{ID: "old", Stopped: &stopped}},
+		"recent":  {partition: TimedPartition{ID: "recent", Stopped: &recent}},
+		"running": {partition: TimedPartition{ID: "running"}},
+	}
+	timedPartitions["prune-empty-test"] = map[string]*partitionRunner{
+		"old": {partition: TimedPartition{ID: "old", Stopped: &stopped}},
+	}
+	partitionMux.Unlock()
+
+	partitions := GetTimedPartitions("prune-test")
+	if len(partitions) != 2 {
+		t.Errorf("expected 2 timed partitions to be kept, got %+v", partitions)
+	}
+	for _, tp := range partitions {
+		if tp.ID == "old" {
+			t.Errorf("expected the old timed partition to be pruned")
+		}
+	}
+	partitionMux.Lock()
+	_, ok := timedPartitions["prune-empty-test"]
+	partitionMux.Unlock()
+	if ok {
+		t.Errorf("expected the testnet without timed partitions to be removed")
+	}
+}
@@ -0,0 +1,242 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//ScenarioRunning is the state of a scenario which is still executing
+	ScenarioRunning = "running"
+	//ScenarioFinished is the state of a scenario which has executed all of its steps
+	ScenarioFinished = "finished"
+	//ScenarioAborted is the state of a scenario which was stopped before it finished
+	ScenarioAborted = "aborted"
+	//ScenarioFailed is the state of a scenario which stopped due to an error
+	ScenarioFailed = "failed"
+)
+
+//ScenarioStep is a change in the network conditions at a point in time
+type ScenarioStep struct {
+	//At is the number of seconds after the start of the scenario this step is executed
+	At int `json:"at"`
+	//Nodes are the absolute numbers of the nodes affected by this step, all nodes if empty
+	Nodes []int `json:"nodes"`
+	//Netconf is the network conditions to apply to the nodes
+	Netconf *Netconf `json:"netconf,omitempty"`
+	//Clear removes the network conditions from the nodes instead
+	Clear bool `json:"clear"`
+}
+
+//Scenario is a timeline of changes in the network conditions
+type Scenario struct {
+	Steps []ScenarioStep `json:"steps"`
+}
+
+//ScenarioStatus is the progress of a scenario on a testnet
+type ScenarioStatus struct {
+	TestnetID string    `json:"testnetId"`
+	Scenario  Scenario  `json:"scenario"`
+	State     string    `json:"state"`
+	Started   time.Time `json:"started"`
+	//Completed is the number of steps which have been executed
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"`
+}
+
+type scenarioRunner struct {
+	status    ScenarioStatus
+	abort     chan struct{}
+	abortOnce sync.Once
+	//done is closed once the scenario stops running
+	done chan struct{}
+	mux       sync.RWMutex
+}
+
+var (
+	scenarios   = map[string]*scenarioRunner{}
+	scenarioMux = sync.Mutex{}
+)
+
+//Validate checks that the scenario is valid, and sorts its steps by time
+func (s *Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario does not have any steps")
+	}
+	for i, step := range s.Steps {
+		if step.At < 0 {
+			return fmt.Errorf("step %d cannot happen before the start of the scenario", i)
+		}
+		if step.Clear == (step.Netconf != nil) {
+			return fmt.Errorf("step %d must either have network conditions or clear them", i)
+		}
+		if step.Netconf != nil {
+			if err := step.Netconf.Validate(); err != nil {
+				return fmt.Errorf("step %d: %s", i, err.Error())
+			}
+		}
+	}
+	sort.SliceStable(s.Steps, func(i, j int) bool { return s.Steps[i].At < s.Steps[j].At })
+	return nil
+}
+
+func selectNodes(nodes []db.Node, nodeNums []int) ([]db.Node, error) {
+	if len(nodeNums) == 0 {
+		return nodes, nil
+	}
+	out := []db.Node{}
+	for _, num := range nodeNums {
+		node, err := db.GetNodeByAbsNum(nodes, num)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func executeStep(step ScenarioStep, nodes []db.Node) error {
+	selected, err := selectNodes(nodes, step.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if step.Clear {
+		return RemoveAll(selected)
+	}
+	return ApplyToAll(*step.Netconf, selected)
+}
+
+func (sr *scenarioRunner) run(exec func(ScenarioStep) error) {
+	defer close(sr.done)
+	sr.mux.RLock()
+	steps := sr.status.Scenario.Steps
+	start := sr.status.Started
+	sr.mux.RUnlock()
+
+	for _, step := range steps {
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(step.At) * time.Second)))
+		select {
+		case <-sr.abort:
+			timer.Stop()
+			sr.finish(ScenarioAborted, nil)
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-sr.abort: //The abort takes priority over a step which is due at the same time
+			sr.finish(ScenarioAborted, nil)
+			return
+		default:
+		}
+		log.WithFields(log.Fields{"testnet": sr.status.TestnetID, "at": step.At}).Info("executing scenario step")
+		err := exec(step)
+		if err != nil {
+			sr.finish(ScenarioFailed, err)
+			return
+		}
+		sr.mux.Lock()
+		sr.status.Completed++
+		sr.mux.Unlock()
+	}
+	sr.finish(ScenarioFinished, nil)
+}
+
+func (sr *scenarioRunner) finish(state string, err error) {
+	sr.mux.Lock()
+	defer sr.mux.Unlock()
+	sr.status.State = state
+	if err != nil {
+		sr.status.Error = err.Error()
+	}
+}
+
+func startScenario(testnetID string, scenario Scenario, exec func(ScenarioStep) error) error {
+	err := scenario.Validate()
+	if err != nil {
+		return util.LogError(err)
+	}
+	scenarioMux.Lock()
+	defer scenarioMux.Unlock()
+	if prev, ok := scenarios[testnetID]; ok && prev.getStatus().State == ScenarioRunning {
+		return fmt.Errorf("a scenario is already running on testnet %s", testnetID)
+	}
+	sr := &scenarioRunner{
+		status: ScenarioStatus{
+			TestnetID: testnetID,
+			Scenario:  scenario,
+			State:     ScenarioRunning,
+			Started:   time.Now(),
+			Total:     len(scenario.Steps),
+		},
+		abort: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	scenarios[testnetID] = sr
+	go sr.run(exec)
+	return nil
+}
+
+func (sr *scenarioRunner) getStatus() ScenarioStatus {
+	sr.mux.RLock()
+	defer sr.mux.RUnlock()
+	return sr.status
+}
+
+//StartScenario starts running the given scenario on the given nodes in the background. Only one
+//scenario can be running on a testnet at a time.
+func StartScenario(testnetID string, scenario Scenario, nodes []db.Node) error {
+	return startScenario(testnetID, scenario, func(step ScenarioStep) error {
+		return executeStep(step, nodes)
+	})
+}
+
+//GetScenarioStatus gets the status of the latest scenario on the given testnet
+func GetScenarioStatus(testnetID string) (ScenarioStatus, error) {
+	scenarioMux.Lock()
+	defer scenarioMux.Unlock()
+	sr, ok := scenarios[testnetID]
+	if !ok {
+		return ScenarioStatus{}, fmt.Errorf("no scenario has been run on testnet %s", testnetID)
+	}
+	return sr.getStatus(), nil
+}
+
+//AbortScenario stops the scenario running on the given testnet, the network conditions
+//already applied by the scenario are left in place. Waits for the step being executed, if any,
+//to finish, so that no more changes are made by the scenario once it returns.
+func AbortScenario(testnetID string) error {
+	scenarioMux.Lock()
+	sr, ok := scenarios[testnetID]
+	if !ok || sr.getStatus().State != ScenarioRunning {
+		scenarioMux.Unlock()
+		return fmt.Errorf("no scenario is running on testnet %s", testnetID)
+	}
+	sr.abortOnce.Do(func() { close(sr.abort) })
+	scenarioMux.Unlock()
+	<-sr.done
+	return nil
+}
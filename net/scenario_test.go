@@ -0,0 +1,177 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestScenario_Validate(t *testing.T) {
+	var test = []struct {
+		scenario Scenario
+		isValid  bool
+	}{
+		{scenario: Scenario{}, isValid: false},
+		{scenario: Scenario{Steps: []ScenarioStep{{At: 0, Netconf: &Netconf{Delay: 50000}}}}, isValid: true},
+		{scenario: Scenario{Steps: []ScenarioStep{{At: -1, Clear: true}}}, isValid: false},
+		{scenario: Scenario{Steps: []ScenarioStep{{At: 5}}}, isValid: false},
+		{scenario: Scenario{Steps: []ScenarioStep{{At: 5, Clear: true, Netconf: &Netconf{}}}}, isValid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.scenario.Validate()
+			if (err == nil) != tt.isValid {
+				t.Errorf("unexpected result from Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestScenario_ValidateSorts(t *testing.T) {
+	scenario := Scenario{Steps: []ScenarioStep{{At: 300, Clear: true}, {At: 0, Netconf: &Netconf{}}, {At: 120, Clear: true}}}
+	err := scenario.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, at := range []int{0, 120, 300} {
+		if scenario.Steps[i].At != at {
+			t.Errorf("expected step %d to be at %d, got %d", i, at, scenario.Steps[i].At)
+		}
+	}
+}
+
+func waitForScenario(t *testing.T, testnetID string) ScenarioStatus {
+	for i := 0; i < 100; i++ {
+		status, err := GetScenarioStatus(testnetID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.State != ScenarioRunning {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("scenario did not finish")
+	return ScenarioStatus{}
+}
+
+func TestStartScenario(t *testing.T) {
+	executed := make(chan ScenarioStep, 2)
+	scenario := Scenario{Steps: []ScenarioStep{{At: 0, Netconf: &Netconf{Loss: 10}}, {At: 0, Clear: true}}}
+	err := startScenario("scenario-test-1", scenario, func(step ScenarioStep) error {
+		executed <- step
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := waitForScenario(t, "scenario-test-1")
+	if status.State != ScenarioFinished || status.Completed != 2 || status.Total != 2 {
+		t.Errorf("unexpected scenario status %+v", status)
+	}
+	if len(executed) != 2 {
+		t.Errorf("expected 2 steps to be executed, got %d", len(executed))
+	}
+}
+
+func TestStartScenario_Failed(t *testing.T) {
+	scenario := Scenario{Steps: []ScenarioStep{{At: 0, Clear: true}, {At: 0, Clear: true}}}
+	err := startScenario("scenario-test-2", scenario, func(step ScenarioStep) error {
+		return fmt.Errorf("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := waitForScenario(t, "scenario-test-2")
+	if status.State != ScenarioFailed || status.Completed != 0 || status.Error != "failed" {
+		t.Errorf("unexpected scenario status %+v", status)
+	}
+}
+
+func TestAbortScenario(t *testing.T) {
+	scenario := Scenario{Steps: []ScenarioStep{{At: 1000, Clear: true}}}
+	err := startScenario("scenario-test-3", scenario, func(step ScenarioStep) error {
+		t.Errorf("step should not have been executed")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = startScenario("scenario-test-3", scenario, nil)
+	if err == nil {
+		t.Errorf("should not be able to run two scenarios on the same testnet at once")
+	}
+
+	err = AbortScenario("scenario-test-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := waitForScenario(t, "scenario-test-3")
+	if status.State != ScenarioAborted {
+		t.Errorf("unexpected scenario status %+v", status)
+	}
+	err = AbortScenario("scenario-test-3")
+	if err == nil {
+		t.Errorf("should not be able to abort a scenario which is not running")
+	}
+}
+
+func TestAbortScenario_Executing(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scenario := Scenario{Steps: []ScenarioStep{{At: 0, Clear: true}, {At: 1000, Clear: true}}}
+	err := startScenario("scenario-test-4", scenario, func(step ScenarioStep) error {
+		if step.At != 0 {
+			t.Errorf("step at %d should not have been executed", step.At)
+			return nil
+		}
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	aborted := make(chan error)
+	go func() {
+		aborted <- AbortScenario("scenario-test-4")
+	}()
+	select {
+	case <-aborted:
+		t.Fatal("abort should wait for the step being executed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err = <-aborted; err != nil {
+		t.Fatal(err)
+	}
+	status, err := GetScenarioStatus("scenario-test-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != ScenarioAborted || status.Completed != 1 {
+		t.Errorf("unexpected scenario status %+v", status)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	//fiberSpeed is the speed of light in fiber, in km per ms
+	fiberSpeed = 200.0
+	//routeInflation is how much longer the route taken by packets is, than the great circle distance
+	routeInflation = 1.4
+	//baseRTT is the round trip time in ms added by switching and the last mile
+	baseRTT = 2.0
+	//earthRadius is the mean radius of the earth in km
+	earthRadius = 6371.0
+)
+
+//Region is a location in which nodes can be placed
+type Region struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+//Topology is a named set of regions, along with the round trip times between them
+type Topology struct {
+	Name    string   `json:"name"`
+	Regions []Region `json:"regions"`
+	//RTT is the round trip time in ms between each of the regions, RTT[i][i] is the rtt within region i
+	RTT [][]float64 `json:"rtt"`
+}
+
+var topologies = map[string]Topology{
+	"single-datacenter": {
+		Name:    "single-datacenter",
+		Regions: []Region{{Name: "us-east", Lat: 38.95, Lon: -77.45}},
+		RTT:     [][]float64{{0.25}},
+	},
+	"us-eu-asia": {
+		Name: "us-eu-asia",
+		Regions: []Region{
+			{Name: "us-east", Lat: 38.95, Lon: -77.45},
+			{Name: "eu-central", Lat: 50.11, Lon: 8.68},
+			{Name: "ap-northeast", Lat: 35.68, Lon: 139.69},
+		},
+		RTT: [][]float64{
+			{1.0, 89.0, 146.0},
+			{89.0, 1.0, 224.0},
+			{146.0, 224.0, 1.0},
+		},
+	},
+	"global-50-cities": {
+		Name: "global-50-cities",
+		Regions: []Region{
+			{Name: "new-york", Lat: 40.71, Lon: -74.01},
+			{Name: "los-angeles", Lat: 34.05, Lon: -118.24},
+			{Name: "chicago", Lat: 41.88, Lon: -87.63},
+			{Name: "dallas", Lat: 32.78, Lon: -96.80},
+			{Name: "miami", Lat: 25.76, Lon: -80.19},
+			{Name: "seattle", Lat: 47.61, Lon: -122.33},
+			{Name: "toronto", Lat: 43.65, Lon: -79.38},
+			{Name: "vancouver", Lat: 49.28, Lon: -123.12},
+			{Name: "mexico-city", Lat: 19.43, Lon: -99.13},
+			{Name: "honolulu", Lat: 21.31, Lon: -157.86},
+			{Name: "bogota", Lat: 4.71, Lon: -74.07},
+			{Name: "lima", Lat: -12.05, Lon: -77.04},
+			{Name: "sao-paulo", Lat: -23.55, Lon: -46.63},
+			{Name: "buenos-aires", Lat: -34.60, Lon: -58.38},
+			{Name: "santiago", Lat: -33.45, Lon: -70.67},
+			{Name: "london", Lat: 51.51, Lon: -0.13},
+			{Name: "dublin", Lat: 53.35, Lon: -6.26},
+			{Name: "paris", Lat: 48.86, Lon: 2.35},
+			{Name: "amsterdam", Lat: 52.37, Lon: 4.90},
+			{Name: "frankfurt", Lat: 50.11, Lon: 8.68},
+			{Name: "zurich", Lat: 47.38, Lon: 8.54},
+			{Name: "milan", Lat: 45.46, Lon: 9.19},
+			{Name: "madrid", Lat: 40.42, Lon: -3.70},
+			{Name: "stockholm", Lat: 59.33, Lon: 18.07},
+			{Name: "warsaw", Lat: 52.23, Lon: 21.01},
+			{Name: "moscow", Lat: 55.76, Lon: 37.62},
+			{Name: "istanbul", Lat: 41.01, Lon: 28.98},
+			{Name: "tel-aviv", Lat: 32.09, Lon: 34.78},
+			{Name: "cairo", Lat: 30.04, Lon: 31.24},
+			{Name: "lagos", Lat: 6.52, Lon: 3.38},
+			{Name: "nairobi", Lat: -1.29, Lon: 36.82},
+			{Name: "johannesburg", Lat: -26.20, Lon: 28.05},
+			{Name: "dubai", Lat: 25.20, Lon: 55.27},
+			{Name: "mumbai", Lat: 19.08, Lon: 72.88},
+			{Name: "delhi", Lat: 28.70, Lon: 77.10},
+			{Name: "bangalore", Lat: 12.97, Lon: 77.59},
+			{Name: "singapore", Lat: 1.35, Lon: 103.82},
+			{Name: "jakarta", Lat: -6.21, Lon: 106.85},
+			{Name: "bangkok", Lat: 13.76, Lon: 100.50},
+			{Name: "hong-kong", Lat: 22.32, Lon: 114.17},
+			{Name: "taipei", Lat: 25.03, Lon: 121.57},
+			{Name: "manila", Lat: 14.60, Lon: 120.98},
+			{Name: "shanghai", Lat: 31.23, Lon: 121.47},
+			{Name: "beijing", Lat: 39.90, Lon: 116.41},
+			{Name: "seoul", Lat: 37.57, Lon: 126.98},
+			{Name: "tokyo", Lat: 35.68, Lon: 139.69},
+			{Name: "osaka", Lat: 34.69, Lon: 135.50},
+			{Name: "sydney", Lat: -33.87, Lon: 151.21},
+			{Name: "melbourne", Lat: -37.81, Lon: 144.96},
+			{Name: "auckland", Lat: -36.85, Lon: 174.76},
+		},
+	},
+}
+
+func init() {
+	for name, topology := range topologies {
+		if topology.RTT == nil {
+			topology.RTT = calculateRTTs(topology.Regions)
+			topologies[name] = topology
+		}
+	}
+}
+
+//GreatCircleDistance calculates the distance in km between two regions along the surface of the earth
+func GreatCircleDistance(r1 Region, r2 Region) float64 {
+	lat1 := r1.Lat * math.Pi / 180
+	lat2 := r2.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (r2.Lon - r1.Lon) * math.Pi / 180
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+//EstimateRTT estimates the round trip time in ms over the given distance in km
+func EstimateRTT(dist float64) float64 {
+	return baseRTT + 2*dist*routeInflation/fiberSpeed
+}
+
+func calculateRTTs(regions []Region) [][]float64 {
+	out := make([][]float64, len(regions))
+	for i := range regions {
+		out[i] = make([]float64, len(regions))
+		for j := range regions {
+			out[i][j] = EstimateRTT(GreatCircleDistance(regions[i], regions[j]))
+		}
+	}
+	return out
+}
+
+//GetTopology gets the topology preset with the given name
+func GetTopology(name string) (Topology, error) {
+	topology, ok := topologies[name]
+	if !ok {
+		return Topology{}, fmt.Errorf("unknown topology \"%s\"", name)
+	}
+	return topology, nil
+}
+
+//GetTopologyNames gets the names of all of the topology presets
+func GetTopologyNames() []string {
+	out := []string{}
+	for name := range topologies {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+//GetRegionIndex gets the index of the region with the given name
+func (t Topology) GetRegionIndex(name string) (int, error) {
+	for i, region := range t.Regions {
+		if region.Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("topology \"%s\" does not have a region \"%s\"", t.Name, name)
+}
+
+//Assign places the given number of nodes into the regions, in a round robin fashion
+func (t Topology) Assign(nodes int) []int {
+	out := make([]int, nodes)
+	for i := range out {
+		out[i] = i % len(t.Regions)
+	}
+	return out
+}
+
+//CreateLinks generates the link matrix for nodes placed in the given regions, where
+//assignment[i] is the index of the region node i is in. The delay on each link is half
+//of the round trip time between the regions.
+func (t Topology) CreateLinks(assignment []int) ([][]Link, error) {
+	out := make([][]Link, len(assignment))
+	for i, from := range assignment {
+		if from < 0 || from >= len(t.Regions) {
+			return nil, fmt.Errorf("node %d is assigned to a region which does not exist", i)
+		}
+		out[i] = make([]Link, len(assignment))
+		for j, to := range assignment {
+			out[i][j] = Link{EgressNode: i, IngressNode: j}
+			if i == j || to < 0 || to >= len(t.Regions) {
+				continue
+			}
+			out[i][j].Delay = int(t.RTT[from][to] * 1000 / 2)
+		}
+	}
+	return out, nil
+}
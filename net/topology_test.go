@@ -0,0 +1,109 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopologyPresets(t *testing.T) {
+	for _, name := range GetTopologyNames() {
+		t.Run(name, func(t *testing.T) {
+			topology, err := GetTopology(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(topology.RTT) != len(topology.Regions) {
+				t.Fatalf("expected %d rows in the rtt table, got %d", len(topology.Regions), len(topology.RTT))
+			}
+			for i := range topology.RTT {
+				if len(topology.RTT[i]) != len(topology.Regions) {
+					t.Fatalf("expected %d columns in row %d, got %d", len(topology.Regions), i, len(topology.RTT[i]))
+				}
+				for j := range topology.RTT[i] {
+					if topology.RTT[i][j] != topology.RTT[j][i] {
+						t.Errorf("rtt between %d and %d is not symmetric", i, j)
+					}
+					if topology.RTT[i][j] <= 0 {
+						t.Errorf("rtt between %d and %d must be positive", i, j)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetTopology_Unknown(t *testing.T) {
+	_, err := GetTopology("atlantis")
+	if err == nil {
+		t.Errorf("GetTopology should return an error for an unknown preset")
+	}
+}
+
+func TestEstimateRTT(t *testing.T) {
+	london := Region{Name: "london", Lat: 51.51, Lon: -0.13}
+	newYork := Region{Name: "new-york", Lat: 40.71, Lon: -74.01}
+
+	dist := GreatCircleDistance(london, newYork)
+	if dist < 5500 || dist > 5650 {
+		t.Errorf("expected the distance between london and new york to be about 5570km, got %f", dist)
+	}
+	rtt := EstimateRTT(dist)
+	if rtt < 60 || rtt > 90 {
+		t.Errorf("expected the rtt between london and new york to be between 60ms and 90ms, got %f", rtt)
+	}
+}
+
+func TestTopology_CreateLinks(t *testing.T) {
+	topology, err := GetTopology("us-eu-asia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment := topology.Assign(4)
+	if !reflect.DeepEqual(assignment, []int{0, 1, 2, 0}) {
+		t.Errorf("unexpected assignment %v", assignment)
+	}
+
+	links, err := topology.CreateLinks(assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int{
+		{0, 44500, 73000, 500},
+		{44500, 0, 112000, 44500},
+		{73000, 112000, 0, 73000},
+		{500, 44500, 73000, 0},
+	}
+	for i := range links {
+		for j := range links[i] {
+			if links[i][j].EgressNode != i || links[i][j].IngressNode != j {
+				t.Errorf("link %d,%d has the wrong nodes", i, j)
+			}
+			if links[i][j].Delay != expected[i][j] {
+				t.Errorf("expected a delay of %d on link %d,%d, got %d", expected[i][j], i, j, links[i][j].Delay)
+			}
+		}
+	}
+
+	_, err = topology.CreateLinks([]int{0, 3})
+	if err == nil {
+		t.Errorf("CreateLinks should reject nodes placed in regions which do not exist")
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/readiness"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/protocols/eth2"
 	"github.com/whiteblock/genesis/ssh"
@@ -63,6 +64,8 @@ func init() {
 
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
 	registrar.RegisterParams(alias, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterReadinessProbe(blockchain, readiness.EthSyncing(ethereum.RPCPort))
+	registrar.RegisterReadinessProbe(alias, readiness.EthSyncing(ethereum.RPCPort))
 }
 
 // build builds out a fresh new ethereum test network using geth
@@ -148,10 +151,10 @@ func build(tn *testnet.TestNet) error {
 		gethCmd := fmt.Sprintf(
 			`geth --datadir /geth/ %s --rpc --nodiscover --rpcaddr 0.0.0.0`+
 				` --rpcapi "admin,web3,db,eth,net,personal,miner,txpool" --rpccorsdomain "0.0.0.0" --mine`+
-				` --txpool.nolocals --port %d console  2>&1 | tee %s`,
-			getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort, conf.DockerOutputFile)
+				` --txpool.nolocals --port %d`,
+			getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort)
 
-		_, err := client.DockerExecdit(node, fmt.Sprintf("bash -ic '%s'", gethCmd))
+		err := client.DockerRunMainDaemon(node, gethCmd)
 		tn.BuildState.IncrementBuildProgress()
 		return util.LogError(err)
 	})
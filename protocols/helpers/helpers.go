@@ -38,6 +38,8 @@ var conf = util.GetConfig()
 func allNodeExecCon(tn *testnet.TestNet, s settings, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
 	nodes := tn.GetSSHNodes(s.useNew, s.sidecar != -1, s.sidecar)
 	wg := sync.WaitGroup{}
+	errMux := sync.Mutex{}
+	var firstErr error
 	for _, node := range nodes {
 
 		wg.Add(1)
@@ -45,13 +47,23 @@ func allNodeExecCon(tn *testnet.TestNet, s settings, fn func(ssh.Client, *db.Ser
 			defer wg.Done()
 			err := fn(fwdClient, fwdServer, fwdNode)
 			if err != nil {
-				tn.BuildState.ReportError(err)
-				return
+				if s.reportError {
+					tn.BuildState.ReportError(err)
+					return
+				}
+				errMux.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMux.Unlock()
 			}
 		}(tn.Clients[node.GetServerID()], tn.GetServer(node.GetServerID()), node)
 
 	}
 	wg.Wait()
+	if !s.reportError {
+		return firstErr
+	}
 	return tn.BuildState.GetError()
 }
 
@@ -66,6 +78,14 @@ func AllNodeExecCon(tn *testnet.TestNet, fn func(ssh.Client, *db.Server, ssh.Nod
 	return allNodeExecCon(tn, settings{useNew: false, sidecar: -1, reportError: true}, fn)
 }
 
+// AllNodeExecConDR is AllNodeExecCon, but only returns the error, doesn't
+// report it to the build state automatically. Useful for read only operations on a testnet which has
+// already been built, where a failure should not mark the build as errored.
+func AllNodeExecConDR(tn *testnet.TestNet, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
+
+	return allNodeExecCon(tn, settings{useNew: false, sidecar: -1, reportError: false}, fn)
+}
+
 // AllNewNodeExecCon is AllNodeExecCon but executes only for new nodes
 func AllNewNodeExecCon(tn *testnet.TestNet, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
 
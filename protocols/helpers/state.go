@@ -21,8 +21,10 @@ package helpers
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"strconv"
 	"strings"
 )
 
@@ -76,3 +78,83 @@ func GetProtocolGroup(tn *testnet.TestNet) (string, error) {
 	}
 	return out, nil
 }
+
+// GetNodePids gets the process ids of the main blockchain process of the given node. node is the
+// number of the node, as it is stored in the build state.
+func GetNodePids(tn *testnet.TestNet, n ssh.Node, node string) ([]string, error) {
+	cmdsToTry, err := GetCommandExprs(tn, node)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	log.WithFields(log.Fields{"toTry": cmdsToTry}).Info("got the commands to try")
+	out := []string{}
+	for _, cmd := range cmdsToTry {
+		pid, err := tn.Clients[n.GetServerID()].DockerExec(n, fmt.Sprintf(
+			"ps aux | grep '%s' | grep -v grep | grep -v nibbler |  awk '{print $2}'", cmd))
+		if err == nil {
+			out = append(out, strings.Split(pid, "\n")...)
+		}
+	}
+	return out, nil
+}
+
+// SignalNode sends the given signal to the main blockchain process of the given node
+func SignalNode(tn *testnet.TestNet, n ssh.Node, signal string) error {
+	procs, err := GetNodePids(tn, n, strconv.Itoa(n.GetAbsoluteNumber()))
+	if err != nil {
+		return util.LogError(err)
+	}
+	log.WithFields(log.Fields{"procs": procs}).Debug("got the possible process ids")
+
+	for _, pid := range procs {
+		if pid == "" {
+			continue
+		}
+		_, err = tn.Clients[n.GetServerID()].DockerExec(n, fmt.Sprintf("kill -%s %s", signal, pid))
+		if err != nil {
+			log.WithFields(log.Fields{"node": n.GetAbsoluteNumber(), "pid": pid, "error": err}).Debug("failed to signal a process")
+		}
+	}
+	return nil
+}
+
+// RestartNode stops the main blockchain process of the given node, and then starts it again with the
+// same command it was originally started with
+func RestartNode(tn *testnet.TestNet, n ssh.Node) error {
+	node := strconv.Itoa(n.GetAbsoluteNumber())
+	var cmd util.Command
+	ok := tn.BuildState.GetP(node, &cmd)
+	if !ok {
+		return fmt.Errorf("node %s not found", node)
+	}
+	client := tn.Clients[n.GetServerID()]
+	procs, err := GetNodePids(tn, n, node)
+	if err != nil {
+		return util.LogError(err)
+	}
+	log.WithFields(log.Fields{"procs": procs}).Debug("got the possible process ids")
+
+	for _, pid := range procs {
+		if pid == "" {
+			continue
+		}
+		_, err = client.DockerExec(n, fmt.Sprintf("kill -INT %s", pid))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	killedSuccessfully := false
+	for i := uint(0); i < conf.KillRetries; i++ {
+		_, err = client.DockerExec(n,
+			fmt.Sprintf("ps aux | grep '%s' | grep -v grep | grep -v nibbler", strings.Split(cmd.Cmdline, " ")[0]))
+		if err != nil {
+			killedSuccessfully = true
+			break
+		}
+	}
+	if !killedSuccessfully {
+		return fmt.Errorf("unable to kill the blockchain process")
+	}
+	return util.LogError(client.DockerExecdLogAppend(n, cmd.Cmdline))
+}
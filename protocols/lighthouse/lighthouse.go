@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/logparser"
+	"github.com/whiteblock/genesis/protocols/readiness"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
@@ -35,6 +37,8 @@ var conf *util.Config
 const (
 	blockchain = "lighthouse"
 	p2pPort    = 9000
+	// apiPort is the port of the HTTP API of the beacon node
+	apiPort = 5052
 )
 
 func init() {
@@ -45,6 +49,9 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterReadinessProbe(blockchain, readiness.BeaconHead(apiPort, "/beacon/head", "slot"))
+	registrar.RegisterLogParsers(blockchain, map[string]logparser.Parser{
+		conf.DockerOutputFile: logparser.Slog})
 }
 
 // build builds out a fresh new lighthouse test network
@@ -0,0 +1,253 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package logparser contains parsers which turn the log lines of the blockchains into structured entries
+package logparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// LevelTrace is the normalized trace level
+	LevelTrace = "trace"
+	// LevelDebug is the normalized debug level
+	LevelDebug = "debug"
+	// LevelInfo is the normalized info level
+	LevelInfo = "info"
+	// LevelWarn is the normalized warning level
+	LevelWarn = "warn"
+	// LevelError is the normalized error level
+	LevelError = "error"
+	// LevelFatal is the normalized level of critical, fatal and panic messages
+	LevelFatal = "fatal"
+)
+
+var levels = map[string]string{
+	"trce":     LevelTrace,
+	"trace":    LevelTrace,
+	"dbug":     LevelDebug,
+	"debg":     LevelDebug,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"inf":      LevelInfo,
+	"warn":     LevelWarn,
+	"warning":  LevelWarn,
+	"wrn":      LevelWarn,
+	"eror":     LevelError,
+	"erro":     LevelError,
+	"error":    LevelError,
+	"err":      LevelError,
+	"crit":     LevelFatal,
+	"critical": LevelFatal,
+	"fatal":    LevelFatal,
+	"fata":     LevelFatal,
+	"panic":    LevelFatal,
+}
+
+var (
+	levelKeys   = []string{"level", "lvl", "severity"}
+	timeKeys    = []string{"time", "ts", "timestamp", "t"}
+	messageKeys = []string{"msg", "message"}
+
+	timestampRegex = regexp.MustCompile(
+		`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\d{2}-\d{2}\|\d{2}:\d{2}:\d{2}(?:\.\d+)?)\]?\s*`)
+	levelRegex    = regexp.MustCompile(`^\[?([A-Za-z]{3,8})\]?\s+`)
+	slogRegex     = regexp.MustCompile(`^([A-Z][a-z]{2} \d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)\s+([A-Z]{4})\s+(.*)$`)
+	logfmtRegex   = regexp.MustCompile(`([A-Za-z0-9_.\-/]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+	trailingRegex = regexp.MustCompile(`(?:\s+[A-Za-z0-9_.\-/]+=(?:"(?:[^"\\]|\\.)*"|\S*))+\s*$`)
+)
+
+// Entry is a structured log line
+type Entry struct {
+	Level     string                 `json:"level,omitempty"`
+	Timestamp string                 `json:"timestamp,omitempty"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Parser turns a line of a log into an Entry, returning false if the line is not in the format
+// the parser understands
+type Parser func(line string) (Entry, bool)
+
+// NormalizeLevel turns the level names used by the different logging libraries, such as EROR
+// and warning, into one of the Level constants. Returns an empty string for an unknown level.
+func NormalizeLevel(level string) string {
+	return levels[strings.ToLower(strings.TrimSpace(level))]
+}
+
+// takeString removes the first of the keys found in fields and returns its value as a string
+func takeString(fields map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		val, ok := fields[key]
+		if !ok {
+			continue
+		}
+		delete(fields, key)
+		if str, ok := val.(string); ok {
+			return str
+		}
+		return fmt.Sprint(val)
+	}
+	return ""
+}
+
+// JSON parses lines which are JSON objects, such as those of logrus's JSONFormatter or zap
+func JSON(line string) (Entry, bool) {
+	fields := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return Entry{}, false
+	}
+	entry := Entry{
+		Level:     NormalizeLevel(takeString(fields, levelKeys)),
+		Timestamp: takeString(fields, timeKeys),
+		Message:   takeString(fields, messageKeys),
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry, true
+}
+
+// parseLogfmt parses key=value pairs, where the value may be quoted
+func parseLogfmt(text string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, match := range logfmtRegex.FindAllStringSubmatch(text, -1) {
+		val := match[2]
+		if strings.HasPrefix(val, "\"") {
+			var unquoted string
+			if err := json.Unmarshal([]byte(val), &unquoted); err == nil {
+				val = unquoted
+			} else {
+				val = strings.Trim(val, "\"")
+			}
+		}
+		out[match[1]] = val
+	}
+	return out
+}
+
+// Logfmt parses lines of key=value pairs, such as those of logrus's TextFormatter
+func Logfmt(line string) (Entry, bool) {
+	fields := parseLogfmt(line)
+	if len(fields) == 0 {
+		return Entry{}, false
+	}
+	_, hasMsg := fields["msg"]
+	_, hasLevel := fields["level"]
+	if !hasMsg && !hasLevel {
+		return Entry{}, false
+	}
+	entry := Entry{
+		Level:     NormalizeLevel(takeString(fields, levelKeys)),
+		Timestamp: takeString(fields, timeKeys),
+		Message:   takeString(fields, messageKeys),
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry, true
+}
+
+// Slog parses lines of the terminal format of rust's slog, such as
+// "May 06 15:04:05.123 INFO Connected to peer, peer: 16Uiu2H, count: 2"
+func Slog(line string) (Entry, bool) {
+	matches := slogRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return Entry{}, false
+	}
+	level := NormalizeLevel(matches[2])
+	if len(level) == 0 {
+		return Entry{}, false
+	}
+	entry := Entry{Level: level, Timestamp: matches[1]}
+	parts := strings.Split(matches[3], ", ")
+	entry.Message = parts[0]
+	fields := map[string]interface{}{}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, ": ", 2)
+		if len(kv) != 2 {
+			entry.Message += ", " + part
+			continue
+		}
+		fields[kv[0]] = kv[1]
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry, true
+}
+
+// Text parses lines which start with a timestamp and a level, in either order, followed by the
+// message and any trailing key=value pairs. This covers the formats of geth, "INFO [05-06|15:04:05.123] msg k=v",
+// and of logrus's prefixed formatter, "[2019-05-06 15:04:05]  INFO sync: msg k=v".
+func Text(line string) (Entry, bool) {
+	entry := Entry{}
+	rest := strings.TrimSpace(line)
+	for i := 0; i < 2; i++ {
+		if matches := timestampRegex.FindStringSubmatch(rest); matches != nil && len(entry.Timestamp) == 0 {
+			entry.Timestamp = matches[1]
+			rest = rest[len(matches[0]):]
+			continue
+		}
+		if matches := levelRegex.FindStringSubmatch(rest); matches != nil && len(entry.Level) == 0 {
+			level := NormalizeLevel(matches[1])
+			if len(level) > 0 {
+				entry.Level = level
+				rest = rest[len(matches[0]):]
+				continue
+			}
+		}
+		break
+	}
+	if len(entry.Level) == 0 {
+		return Entry{}, false
+	}
+	if loc := trailingRegex.FindStringIndex(rest); loc != nil {
+		entry.Fields = parseLogfmt(rest[loc[0]:])
+		rest = rest[:loc[0]]
+	}
+	entry.Message = strings.TrimSpace(rest)
+	return entry, true
+}
+
+// Raw never fails, making the whole line the message
+func Raw(line string) (Entry, bool) {
+	return Entry{Message: line}, true
+}
+
+// Auto tries each of the parsers in turn, falling back to Raw when none of them understand the line
+func Auto(line string) (Entry, bool) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		if entry, ok := JSON(trimmed); ok {
+			return entry, true
+		}
+	}
+	for _, parser := range []Parser{Slog, Text, Logfmt} {
+		if entry, ok := parser(line); ok {
+			return entry, true
+		}
+	}
+	return Raw(line)
+}
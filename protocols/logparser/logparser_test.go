@@ -0,0 +1,89 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package logparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestAuto(t *testing.T) {
+	var test = []struct {
+		line     string
+		expected Entry
+	}{
+		{
+			line: `{"level":"warning","ts":"2019-05-06T15:04:05Z","msg":"peer dropped","peer":"abc","count":2}`,
+			expected: Entry{Level: LevelWarn, Timestamp: "2019-05-06T15:04:05Z", Message: "peer dropped",
+				Fields: map[string]interface{}{"peer": "abc", "count": json.Number("2")}},
+		},
+		{
+			line: `time="2019-05-06T15:04:05Z" level=error msg="could not sync" prefix=sync slot=12`,
+			expected: Entry{Level: LevelError, Timestamp: "2019-05-06T15:04:05Z", Message: "could not sync",
+				Fields: map[string]interface{}{"prefix": "sync", "slot": "12"}},
+		},
+		{
+			line: `INFO [05-06|15:04:05.123] Imported new chain segment               blocks=1 txs=0 number="1,024"`,
+			expected: Entry{Level: LevelInfo, Timestamp: "05-06|15:04:05.123", Message: "Imported new chain segment",
+				Fields: map[string]interface{}{"blocks": "1", "txs": "0", "number": "1,024"}},
+		},
+		{
+			line:     `[2019-05-06 15:04:05]  WARN sync: Not enough peers`,
+			expected: Entry{Level: LevelWarn, Timestamp: "2019-05-06 15:04:05", Message: "sync: Not enough peers"},
+		},
+		{
+			line: `May 06 15:04:05.123 DEBG Connected to peer, peer: 16Uiu2H, count: 2`,
+			expected: Entry{Level: LevelDebug, Timestamp: "May 06 15:04:05.123", Message: "Connected to peer",
+				Fields: map[string]interface{}{"peer": "16Uiu2H", "count": "2"}},
+		},
+		{
+			line:     `starting the node`,
+			expected: Entry{Message: "starting the node"},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			entry, ok := Auto(tt.line)
+			if !ok {
+				t.Fatal("Auto should never fail")
+			}
+			if !reflect.DeepEqual(entry, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, entry)
+			}
+		})
+	}
+}
+
+func TestNormalizeLevel(t *testing.T) {
+	var test = map[string]string{
+		"EROR":    LevelError,
+		"Warning": LevelWarn,
+		"TRCE":    LevelTrace,
+		"crit":    LevelFatal,
+		"nope":    "",
+	}
+	for level, expected := range test {
+		if NormalizeLevel(level) != expected {
+			t.Errorf("expected %s to normalize to \"%s\", got \"%s\"", level, expected, NormalizeLevel(level))
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/whiteblock/genesis/protocols/ethclassic"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/readiness"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
@@ -50,6 +51,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterReadinessProbe(blockchain, readiness.EthSyncing(ethereum.RPCPort))
 
 	registrar.RegisterBlockchainSideCars(blockchain, func(tn *testnet.TestNet) []string {
 		pconf, err := newConf(tn.LDD.Extras)
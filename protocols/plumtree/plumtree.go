@@ -24,6 +24,7 @@ import (
 
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/logparser"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/protocols/services"
 	"github.com/whiteblock/genesis/ssh"
@@ -47,6 +48,8 @@ func init() {
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
 	registrar.RegisterAdditionalLogs(blockchain, map[string]string{
 		"json": "/plumtree/data/log.json"})
+	registrar.RegisterLogParsers(blockchain, map[string]logparser.Parser{
+		"/plumtree/data/log.json": logparser.JSON})
 }
 
 func getServices() []services.Service {
@@ -27,6 +27,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/logparser"
+	"github.com/whiteblock/genesis/protocols/readiness"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
@@ -40,6 +42,8 @@ const (
 	blockchain    = "prysm"
 	p2pPort       = 3000
 	numValidators = 8
+	// gatewayPort is the port of the JSON gateway to the beacon chain API
+	gatewayPort = 3500
 )
 
 func init() {
@@ -50,6 +54,9 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterReadinessProbe(blockchain, readiness.BeaconHead(gatewayPort, "/eth/v1alpha1/beacon/chainhead", "headSlot"))
+	registrar.RegisterLogParsers(blockchain, map[string]logparser.Parser{
+		conf.DockerOutputFile: logparser.Text})
 }
 
 // build builds out a fresh new prysm test network
@@ -127,13 +134,13 @@ func build(tn *testnet.TestNet) error {
 			logFolder = ""
 		}
 
-		_, err = client.DockerExecd(node,
+		err = client.DockerRunMainDaemon(node,
 			fmt.Sprintf("/prysm/bazel-bin/beacon-chain/linux_amd64_stripped/beacon-chain "+
 				"--monitoring-port=%s --no-discovery %s --log-file %s/beacon-chain%d.log "+
 				" --p2p-priv-key /etc/identity.key --clear-db --hobbits --p2p-port %d --p2p-host-ip %s"+
-				" --verbosity trace",
+				" --grpc-gateway-port %d --verbosity trace",
 				prometheusInstrumentationPort, peers, logFolder,
-				node.GetAbsoluteNumber(), p2pPort, node.GetIP()))
+				node.GetAbsoluteNumber(), p2pPort, node.GetIP(), gatewayPort))
 		if err != nil {
 			return util.LogError(err)
 		}
@@ -149,9 +156,9 @@ func build(tn *testnet.TestNet) error {
 		}
 
 		for i := 1; i <= numValidators; i++ {
-			_, err = client.DockerExecd(node,
-				fmt.Sprintf("bash -c \"/prysm/bazel-bin/validator/linux_amd64_pure_stripped/validator"+
-					" --password %s --keystore-path %s/key%d-%d --monitoring-port 10%d%d 2>&1 | tee /output.log\"",
+			err = client.DockerExecdLogAppend(node,
+				fmt.Sprintf("/prysm/bazel-bin/validator/linux_amd64_pure_stripped/validator"+
+					" --password %s --keystore-path %s/key%d-%d --monitoring-port 10%d%d",
 					validatorsPassword, logFolder, node.GetRelativeNumber(), i, node.GetRelativeNumber(), i))
 			if err != nil {
 				return util.LogError(err)
@@ -0,0 +1,170 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package readiness contains probes which check whether the nodes of the blockchains are ready to use,
+// rather than just running
+package readiness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// timeout is the number of seconds a probe waits for the node to respond
+const timeout = 5
+
+// Probe checks whether a node is ready
+type Probe struct {
+	// Command gets the command which queries the node with the given ip, it is run on the server of the node
+	Command func(ip string) string
+	// Check works out from the output of the command whether the node is ready, returning why not if it isn't
+	Check func(output string) error
+}
+
+// decode decodes the JSON in the output of a probe, keeping the numbers as they are
+func decode(output string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("unexpected response from the node: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// lookup gets the value at the given dot separated path of a decoded JSON object
+func lookup(obj interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		fields, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		obj, ok = fields[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return obj, true
+}
+
+// HTTPGet probes a node with a GET request to the given port and path, passing the body of the response to check
+func HTTPGet(port int, path string, check func(output string) error) Probe {
+	return Probe{
+		Command: func(ip string) string {
+			return fmt.Sprintf("curl -sSf -m %d http://%s:%d%s", timeout, ip, port, path)
+		},
+		Check: check,
+	}
+}
+
+// JSONRPC probes a node by calling the given JSON-RPC method, without any params, on the given port.
+// check is given the result of the call.
+func JSONRPC(port int, method string, check func(result json.RawMessage) error) Probe {
+	return Probe{
+		Command: func(ip string) string {
+			return fmt.Sprintf(`curl -sS -m %d -X POST http://%s:%d -H "Content-Type: application/json" `+
+				`-d '{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}'`, timeout, ip, port, method)
+		},
+		Check: func(output string) error {
+			var res struct {
+				Result json.RawMessage `json:"result"`
+				Error  *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := decode(output, &res); err != nil {
+				return err
+			}
+			if res.Error != nil {
+				return fmt.Errorf("%s failed: %s", method, res.Error.Message)
+			}
+			return check(res.Result)
+		},
+	}
+}
+
+// parseQuantity parses a hex encoded quantity of the ethereum JSON-RPC API
+func parseQuantity(quantity string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(quantity, "0x"), 16, 64)
+}
+
+// EthSyncing probes an ethereum client with eth_syncing, the node is ready once it is no longer syncing
+func EthSyncing(port int) Probe {
+	return JSONRPC(port, "eth_syncing", func(result json.RawMessage) error {
+		if bytes.Equal(bytes.TrimSpace(result), []byte("false")) {
+			return nil
+		}
+		var progress struct {
+			CurrentBlock string `json:"currentBlock"`
+			HighestBlock string `json:"highestBlock"`
+		}
+		if err := json.Unmarshal(result, &progress); err != nil {
+			return fmt.Errorf("unexpected result from eth_syncing: %s", string(result))
+		}
+		current, err := parseQuantity(progress.CurrentBlock)
+		if err != nil {
+			return fmt.Errorf("unexpected result from eth_syncing: %s", string(result))
+		}
+		highest, err := parseQuantity(progress.HighestBlock)
+		if err != nil {
+			return fmt.Errorf("unexpected result from eth_syncing: %s", string(result))
+		}
+		return fmt.Errorf("syncing, at block %d of %d", current, highest)
+	})
+}
+
+// BeaconHead probes the HTTP API of a beacon node for the slot of the head of the chain, at the dot separated
+// path slot of the response. The node is ready once it knows of its head.
+func BeaconHead(port int, path string, slot string) Probe {
+	return HTTPGet(port, path, func(output string) error {
+		var res interface{}
+		if err := decode(output, &res); err != nil {
+			return err
+		}
+		raw, ok := lookup(res, slot)
+		if !ok {
+			return fmt.Errorf("the head slot is missing from the response of the node")
+		}
+		if _, err := strconv.ParseUint(fmt.Sprint(raw), 10, 64); err != nil {
+			return fmt.Errorf("invalid head slot %v", raw)
+		}
+		return nil
+	})
+}
+
+// TendermintStatus probes the /status endpoint of the RPC server of a tendermint node, the node is ready
+// once it has caught up with the rest of the network
+func TendermintStatus(port int) Probe {
+	return HTTPGet(port, "/status", func(output string) error {
+		var res interface{}
+		if err := decode(output, &res); err != nil {
+			return err
+		}
+		catchingUp, ok := lookup(res, "result.sync_info.catching_up")
+		if !ok {
+			return fmt.Errorf("the sync info is missing from the response of the node")
+		}
+		if catchingUp == true {
+			height, _ := lookup(res, "result.sync_info.latest_block_height")
+			return fmt.Errorf("catching up, at height %v", height)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package readiness
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	var test = []struct {
+		probe  Probe
+		output string
+		ready  bool
+	}{
+		{probe: EthSyncing(8545), output: `{"jsonrpc":"2.0","id":1,"result":false}`, ready: true},
+		{probe: EthSyncing(8545), output: `{"jsonrpc":"2.0","id":1,"result":{"currentBlock":"0x10","highestBlock":"0x20"}}`, ready: false},
+		{probe: EthSyncing(8545), output: `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`, ready: false},
+		{probe: EthSyncing(8545), output: `curl: (7) Failed to connect`, ready: false},
+		{probe: BeaconHead(5052, "/beacon/head", "slot"), output: `{"slot":12,"block_root":"0xab"}`, ready: true},
+		{probe: BeaconHead(3500, "/eth/v1alpha1/beacon/chainhead", "headSlot"), output: `{"headSlot":"12"}`, ready: true},
+		{probe: BeaconHead(5052, "/beacon/head", "slot"), output: `{"block_root":"0xab"}`, ready: false},
+		{probe: TendermintStatus(26657), output: `{"result":{"sync_info":{"latest_block_height":"10","catching_up":false}}}`, ready: true},
+		{probe: TendermintStatus(26657), output: `{"result":{"sync_info":{"latest_block_height":"10","catching_up":true}}}`, ready: false},
+	}
+	for i, tt := range test {
+		err := tt.probe.Check(tt.output)
+		if (err == nil) != tt.ready {
+			t.Errorf("%d: expected ready to be %v, got error %v", i, tt.ready, err)
+		}
+	}
+
+	err := EthSyncing(8545).Check(`{"jsonrpc":"2.0","id":1,"result":{"currentBlock":"0x10","highestBlock":"0x20"}}`)
+	if err == nil || err.Error() != "syncing, at block 16 of 32" {
+		t.Errorf("expected the sync progress in the error, got %v", err)
+	}
+	cmd := EthSyncing(8545).Command("10.1.0.2")
+	if !strings.Contains(cmd, "http://10.1.0.2:8545") || !strings.Contains(cmd, `"method":"eth_syncing"`) {
+		t.Errorf("unexpected command %s", cmd)
+	}
+}
@@ -21,6 +21,8 @@ package registrar
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/protocols/logparser"
+	"github.com/whiteblock/genesis/protocols/readiness"
 	"github.com/whiteblock/genesis/protocols/services"
 	"github.com/whiteblock/genesis/testnet"
 	"sync"
@@ -38,6 +40,8 @@ var (
 	paramsFuncs   = map[string]func() string{}
 	defaultsFuncs = map[string]func() string{}
 	logFiles      = map[string]map[string]string{}
+	logParsers    = map[string]map[string]logparser.Parser{}
+	probes        = map[string]readiness.Probe{}
 )
 
 // RegisterBuild associates a blockchain name with a build process
@@ -82,6 +86,22 @@ func RegisterAdditionalLogs(blockchain string, logs map[string]string) {
 	logFiles[blockchain] = logs
 }
 
+// RegisterLogParsers associates a blockchain name with the parsers for its log files, by the
+// location of the log file in the node
+func RegisterLogParsers(blockchain string, parsers map[string]logparser.Parser) {
+	mux.Lock()
+	defer mux.Unlock()
+	logParsers[blockchain] = parsers
+}
+
+// RegisterReadinessProbe associates a blockchain name with the probe which checks whether its nodes
+// are ready
+func RegisterReadinessProbe(blockchain string, probe readiness.Probe) {
+	mux.Lock()
+	defer mux.Unlock()
+	probes[blockchain] = probe
+}
+
 // GetBuildFunc gets the build function associated with the given blockchain name or error != nil if
 // it is not found
 func GetBuildFunc(blockchain string) (func(*testnet.TestNet) error, error) {
@@ -149,6 +169,26 @@ func GetAdditionalLogs(blockchain string) map[string]string {
 	return logFiles[blockchain]
 }
 
+// GetLogParser gets the parser for the given log file of the blockchain, falling back to
+// logparser.Auto if it does not have one registered
+func GetLogParser(blockchain string, file string) logparser.Parser {
+	mux.RLock()
+	defer mux.RUnlock()
+	parser, ok := logParsers[blockchain][file]
+	if !ok {
+		return logparser.Auto
+	}
+	return parser
+}
+
+// GetReadinessProbe gets the readiness probe of the given blockchain, false if it does not have one
+func GetReadinessProbe(blockchain string) (readiness.Probe, bool) {
+	mux.RLock()
+	defer mux.RUnlock()
+	probe, ok := probes[blockchain]
+	return probe, ok
+}
+
 // GetSupportedBlockchains gets the blockchains which have a registered
 // Build function
 func GetSupportedBlockchains() []string {
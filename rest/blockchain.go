@@ -167,11 +167,23 @@ func getBlockChainLog(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
+	filter, err := getGrepFilter(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if r.URL.Query().Get("follow") == "true" {
+		followBlockChainLog(w, r, client, node, lines, filter)
+		return
+	}
 	res, err := client.DockerRead(node, conf.DockerOutputFile, lines)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("%s %s", res, util.LogError(err).Error()), 500)
 		return
 	}
+	if filter != nil {
+		res = filterLines(res, filter)
+	}
 	w.Write([]byte(res))
 }
 
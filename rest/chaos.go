@@ -0,0 +1,77 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/chaos"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func startChaos(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	testnetID := params["testnetID"]
+
+	var plan chaos.Plan
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&plan)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = plan.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+
+	out, err := chaos.Start(testnetID, plan, getKid(r))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 409)
+		return
+	}
+	recordEvent(r, testnetID, "chaos start", nil, out.Plan)
+	json.NewEncoder(w).Encode(out)
+}
+
+func getChaos(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	out, err := chaos.GetStatus(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func stopChaos(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	err := chaos.Stop(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	recordEvent(r, params["testnetID"], "chaos stop", nil, nil)
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,81 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+// ClockSkew is the offset of the clock of a node
+type ClockSkew struct {
+	// Offset is the number of seconds the clock of the node is ahead by, negative if it is behind
+	Offset float64 `json:"offset"`
+	// Skewed is false if the clock of the node has never been skewed
+	Skewed bool `json:"skewed"`
+}
+
+func getClockSkew(w http.ResponseWriter, r *http.Request) {
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	offset, skewed, err := docker.GetClockSkew(tn.Clients[node.Server], node)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(ClockSkew{Offset: offset, Skewed: skewed})
+}
+
+func setClockSkew(w http.ResponseWriter, r *http.Request) {
+	var skew ClockSkew
+	err := json.NewDecoder(r.Body).Decode(&skew)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	client := tn.Clients[node.Server]
+	_, skewed, err := docker.GetClockSkew(client, node)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = docker.SetClockSkew(client, node, skew.Offset)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, tn.TestNetID, "clock skew", []int{node.AbsoluteNum}, skew)
+	if !skewed {
+		w.Write([]byte(fmt.Sprintf("The clock skew of node %d will take effect once its main process is restarted",
+			node.AbsoluteNum)))
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,123 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+// DiskFill describes how to fill up the disk of a node
+type DiskFill struct {
+	// Path is the directory inside of the node to fill, usually the data directory of the blockchain
+	Path string `json:"path"`
+	// Size is the size of the file to fill the directory with, if not given, all of the space is used up
+	Size string `json:"size,omitempty"`
+	// Free is the amount of space to leave available when Size is not given
+	Free string `json:"free,omitempty"`
+}
+
+func fillDisk(w http.ResponseWriter, r *http.Request) {
+	var fill DiskFill
+	err := json.NewDecoder(r.Body).Decode(&fill)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = util.ValidateFilePath(fill.Path)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	size := int64(-1)
+	if len(fill.Size) > 0 {
+		size, err = util.ParseSize(fill.Size)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	free := int64(0)
+	if len(fill.Free) > 0 {
+		free, err = util.ParseSize(fill.Free)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if dir, ok := tn.GetDiskFill(node.AbsoluteNum); ok {
+		http.Error(w, fmt.Sprintf("%s of node %d is already filled", dir, node.AbsoluteNum), 409)
+		return
+	}
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": node.AbsoluteNum, "fill": fill}).Info("filling the disk of a node")
+	filled, err := docker.FillDisk(tn.Clients[node.Server], node, fill.Path, size, free)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = testnet.Update(tn.TestNetID, func(tn *testnet.TestNet) error {
+		tn.SetDiskFill(node.AbsoluteNum, fill.Path)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, tn.TestNetID, "disk fill", []int{node.AbsoluteNum}, fill)
+	w.Write([]byte(fmt.Sprintf("Filled %s of node %d with %d bytes", fill.Path, node.AbsoluteNum, filled)))
+}
+
+func clearDisk(w http.ResponseWriter, r *http.Request) {
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	dir, ok := tn.GetDiskFill(node.AbsoluteNum)
+	if !ok {
+		http.Error(w, fmt.Sprintf("The disk of node %d is not filled", node.AbsoluteNum), 409)
+		return
+	}
+	err = docker.ClearDisk(tn.Clients[node.Server], node, dir)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = testnet.Update(tn.TestNetID, func(tn *testnet.TestNet) error {
+		tn.SetDiskFill(node.AbsoluteNum, "")
+		return nil
+	})
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, tn.TestNetID, "disk clear", []int{node.AbsoluteNum}, nil)
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,122 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// getKid gets the kid of whoever made the request, empty if the request isn't authenticated
+func getKid(r *http.Request) string {
+	jwt, err := util.ExtractJwt(r)
+	if err != nil {
+		return ""
+	}
+	kid, err := util.GetKidFromJwt(jwt)
+	if err != nil {
+		return ""
+	}
+	return kid
+}
+
+// recordEvent adds an action taken on a testnet to its journal. A failure to record the event
+// is logged, but does not fail the action.
+func recordEvent(r *http.Request, testnetID string, action string, nodes []int, details interface{}) {
+	_, err := db.InsertEvent(db.Event{
+		TestNetID: testnetID,
+		Kid:       getKid(r),
+		Action:    action,
+		Nodes:     nodes,
+		Details:   details,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": testnetID, "action": action, "error": err}).Error("failed to record an event")
+	}
+}
+
+// getNetconfNodes gets the absolute numbers of the nodes targeted by the given network conditions, which
+// refer to the nodes by their local ids
+func getNetconfNodes(netconfs []netem.Netconf, nodes []db.Node) []int {
+	out := []int{}
+	for _, netconf := range netconfs {
+		node, err := db.GetNodeByLocalID(nodes, netconf.Node)
+		if err != nil {
+			continue
+		}
+		out = append(out, node.AbsoluteNum)
+	}
+	return out
+}
+
+func getEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	events, err := db.GetEventsByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+
+	query := r.URL.Query()
+	var since time.Time
+	if len(query.Get("since")) > 0 {
+		since, err = time.Parse(time.RFC3339, query.Get("since"))
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	node := -1
+	if len(query.Get("node")) > 0 {
+		node, err = strconv.Atoi(query.Get("node"))
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	action := query.Get("action")
+
+	out := []db.Event{}
+	for _, event := range events {
+		if len(action) > 0 && event.Action != action {
+			continue
+		}
+		if event.Time.Before(since) {
+			continue
+		}
+		if node != -1 && len(event.Nodes) > 0 {
+			found := false
+			for _, num := range event.Nodes {
+				found = found || num == node
+			}
+			if !found {
+				continue
+			}
+		}
+		out = append(out, event)
+	}
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,308 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/archive"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/logparser"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LogEntry is a parsed line of the logs of a node
+type LogEntry struct {
+	File string `json:"file"`
+	logparser.Entry
+}
+
+// logEntryFilter holds the filters on the parsed log entries
+type logEntryFilter struct {
+	levels map[string]bool
+	fields map[string]string
+}
+
+// getLogEntryFilter gets the filters from the level and field query parameters. level is a comma
+// separated list of levels, and field is given as key=value, and can be given more than once.
+func getLogEntryFilter(r *http.Request) (logEntryFilter, error) {
+	out := logEntryFilter{levels: map[string]bool{}, fields: map[string]string{}}
+	query := r.URL.Query()
+	if len(query.Get("level")) > 0 {
+		for _, level := range strings.Split(query.Get("level"), ",") {
+			normalized := logparser.NormalizeLevel(level)
+			if len(normalized) == 0 {
+				return out, fmt.Errorf("unknown log level \"%s\"", level)
+			}
+			out.levels[normalized] = true
+		}
+	}
+	for _, field := range query["field"] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return out, fmt.Errorf("invalid field filter \"%s\", expected key=value", field)
+		}
+		out.fields[kv[0]] = kv[1]
+	}
+	return out, nil
+}
+
+func (filter logEntryFilter) matches(entry logparser.Entry) bool {
+	if len(filter.levels) > 0 && !filter.levels[entry.Level] {
+		return false
+	}
+	for key, val := range filter.fields {
+		actual, ok := entry.Fields[key]
+		if !ok || fmt.Sprint(actual) != val {
+			return false
+		}
+	}
+	return true
+}
+
+// getGrepFilter gets the regex given in the grep query parameter, nil if there isn't one
+func getGrepFilter(r *http.Request) (*regexp.Regexp, error) {
+	pattern := r.URL.Query().Get("grep")
+	if len(pattern) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// filterLines keeps only the lines of text which match re
+func filterLines(text string, re *regexp.Regexp) string {
+	out := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if re.MatchString(line) {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// getNodeLogFiles gets the output file of the node, along with the additional log files of its protocol
+func getNodeLogFiles(node db.Node) []string {
+	out := []string{conf.DockerOutputFile}
+	additional := registrar.GetAdditionalLogs(node.Protocol)
+	names := []string{}
+	for name := range additional {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, additional[name])
+	}
+	return out
+}
+
+// readLogFile reads a log file of the node like DockerRead, except that a log file which has not been created
+// yet reads as empty, as with the log search and the archives
+func readLogFile(client ssh.Client, node ssh.Node, file string, lines int) (string, error) {
+	read := fmt.Sprintf("cat %s", file)
+	if lines > -1 {
+		read = fmt.Sprintf("tail -n %d %s", lines, file)
+	}
+	return client.DockerExec(node, fmt.Sprintf("sh -c %s", util.ShellQuote(fmt.Sprintf("[ -f %s ] || exit 0; %s", file, read))))
+}
+
+// lineWriter splits the output of tail into lines, dropping the ones which do not match
+// the filter, and flushing each line as soon as it is written. When following multiple
+// files, each line is prefixed with the file it came from.
+type lineWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	filter  *regexp.Regexp
+	prefix  bool
+	file    string
+	buf     []byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i == -1 {
+			break
+		}
+		line := string(lw.buf[:i])
+		lw.buf = lw.buf[i+1:]
+
+		if strings.HasPrefix(line, "==> ") && strings.HasSuffix(line, " <==") {
+			lw.file = strings.TrimSuffix(strings.TrimPrefix(line, "==> "), " <==")
+			continue
+		}
+		if lw.filter != nil && !lw.filter.MatchString(line) {
+			continue
+		}
+		if lw.prefix && len(line) > 0 {
+			line = lw.file + ": " + line
+		}
+		_, err := lw.w.Write([]byte(line + "\n"))
+		if err != nil {
+			return 0, err
+		}
+	}
+	lw.flusher.Flush()
+	return len(p), nil
+}
+
+// followBlockChainLog streams the new lines of the logs of the node until the request is cancelled
+func followBlockChainLog(w http.ResponseWriter, r *http.Request, client ssh.Client, node db.Node,
+	lines int, filter *regexp.Regexp) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", 500)
+		return
+	}
+	files := getNodeLogFiles(node)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher.Flush()
+
+	out := &lineWriter{w: w, flusher: flusher, filter: filter, prefix: len(files) > 1}
+	err := client.DockerFollow(r.Context(), node, files, lines, out)
+	if err != nil {
+		util.LogError(err)
+	}
+}
+
+func createLogArchive(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	out, err := archive.Create(tn, "requested")
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func getLogArchives(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := archive.List(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func downloadLogArchive(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	path, err := archive.GetPath(params["id"], params["name"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%s\"", params["id"], params["name"]))
+	http.ServeFile(w, r, path)
+}
+
+// getParsedLogs gets the logs of a node parsed with the parsers registered by its blockchain, only
+// returning the entries which match the given level and field filters
+func getParsedLogs(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodeNum, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	lines, err := getIntQuery(r, "lines", 0)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if lines == 0 {
+		lines = -1
+	}
+	filter, err := getLogEntryFilter(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	node, err := db.GetNodeByAbsNum(nodes, nodeNum)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	files := getNodeLogFiles(node)
+	if name := r.URL.Query().Get("file"); len(name) > 0 {
+		file, ok := registrar.GetAdditionalLogs(node.Protocol)[name]
+		if name == "output" {
+			file, ok = conf.DockerOutputFile, true
+		}
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown log \"%s\"", name), 404)
+			return
+		}
+		files = []string{file}
+	}
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+
+	out := []LogEntry{}
+	for _, file := range files {
+		res, err := readLogFile(client, node, file, lines)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s %s", res, util.LogError(err).Error()), 500)
+			return
+		}
+		parser := registrar.GetLogParser(node.Protocol, file)
+		for _, line := range strings.Split(res, "\n") {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			entry, ok := parser(line)
+			if !ok {
+				entry, _ = logparser.Raw(line)
+			}
+			if filter.matches(entry) {
+				out = append(out, LogEntry{File: file, Entry: entry})
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(out)
+}
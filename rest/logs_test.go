@@ -0,0 +1,46 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func TestReadLogFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0, IP: "10.1.0.2"}
+	client.EXPECT().DockerExec(node, `sh -c '[ -f /beacon.log ] || exit 0; tail -n 10 /beacon.log'`).Return("", nil)
+	client.EXPECT().DockerExec(node, `sh -c '[ -f /output.log ] || exit 0; cat /output.log'`).Return("line\n", nil)
+
+	res, err := readLogFile(client, node, "/beacon.log", 10)
+	if err != nil || res != "" {
+		t.Errorf("expected a log file which does not exist yet to read as empty, got \"%s\", %v", res, err)
+	}
+	res, err = readLogFile(client, node, "/output.log", -1)
+	if err != nil || res != "line\n" {
+		t.Errorf("expected the whole log file, got \"%s\", %v", res, err)
+	}
+}
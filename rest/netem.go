@@ -19,6 +19,7 @@
 package rest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -41,6 +42,13 @@ func handleNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	for _, nconf := range netConf {
+		err = nconf.Validate()
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
@@ -53,6 +61,7 @@ func handleNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	recordEvent(r, params["testnetID"], "netem", getNetconfNodes(netConf, nodes), netConf)
 	w.Write([]byte("Success"))
 }
 
@@ -68,6 +77,11 @@ func handleNetAll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	err = netConf.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
@@ -79,10 +93,62 @@ func handleNetAll(w http.ResponseWriter, r *http.Request) {
 	err = netem.ApplyToAll(netConf, nodes)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, params["testnetID"], "netem all", nil, netConf)
+	w.Write([]byte("Success"))
+}
+
+func handleLinks(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	var raw json.RawMessage
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&raw)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+
+	var links [][]netem.Link
+	//The body is either the link matrix or the coordinates of each node
+	first := bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(raw), []byte("[")))
+	if bytes.HasPrefix(first, []byte("[")) {
+		err = json.Unmarshal(raw, &links)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	} else {
+		var points []util.Point
+		err = json.Unmarshal(raw, &points)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+		links = netem.CreateLinks(points, nil)
+	}
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+
+	err = netem.ApplyLinks(links, nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
 	}
+	recordEvent(r, params["testnetID"], "links", nil, nil)
 	w.Write([]byte("Success"))
 }
 
+func getBandwidthPresets(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(netem.GetBandwidthPresets())
+}
+
 func stopNet(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -93,7 +159,7 @@ func stopNet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	netem.RemoveAll(nodes)
-
+	recordEvent(r, params["testnetID"], "netem clear", nil, nil)
 	w.Write([]byte("Success"))
 }
 
@@ -122,6 +188,39 @@ func getNet(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(out)
 }
 
+func getNetDrift(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	out, err := netem.CheckDrift(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func reconcileNet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	out, err := netem.Reconcile(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, params["testnetID"], "netem reconcile", nil, out)
+	json.NewEncoder(w).Encode(out)
+}
+
 func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	testnetID := params["testnetID"]
@@ -154,10 +253,15 @@ func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	switch r.Method {
-	case "POST":
+	oneWay := r.URL.Query().Get("oneway") == "true" //node1 can no longer send to node2
+	switch {
+	case r.Method == "POST" && oneWay:
+		err = netem.MakeOneWayOutage(node1, node2)
+	case r.Method == "POST":
 		err = netem.MakeOutage(node1, node2)
-	case "DELETE":
+	case r.Method == "DELETE" && oneWay:
+		err = netem.RemoveOneWayOutage(node1, node2)
+	case r.Method == "DELETE":
 		err = netem.RemoveOutage(node1, node2)
 	default:
 		err = fmt.Errorf("unexpected http method")
@@ -166,6 +270,11 @@ func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	action := "outage"
+	if r.Method == "DELETE" {
+		action = "outage remove"
+	}
+	recordEvent(r, testnetID, action, []int{nodeNum1, nodeNum2}, map[string]bool{"oneway": oneWay})
 	w.Write([]byte("Success"))
 }
 
@@ -190,7 +299,12 @@ func partitionOutage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	netem.CreatePartitionOutage(side1, side2)
+	err = netem.CreatePartitionOutage(side1, side2)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, params["testnetID"], "partition", nodeNums, nil)
 	w.Write([]byte("success"))
 }
 
@@ -215,30 +329,25 @@ func removeAllOutages(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	recordEvent(r, params["testnetID"], "outage remove all", nil, nil)
 	w.Write([]byte("Success"))
 }
 
 func getAllOutages(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	servers, err := status.GetLatestServers(params["testnetID"])
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	out := []netem.Connection{}
-	for _, server := range servers {
-		client, err := status.GetClient(server.ID)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 404)
-			return
-		}
-		conns, err := netem.GetCutConnections(client)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 500)
-			return
-		}
-		out = append(out, conns...)
+	out, err := netem.GetAllCutConnections(nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if r.URL.Query().Get("oneway") == "true" {
+		out = netem.FilterOneWay(out)
 	}
 	nodeRaw, exists := params["node"]
 	if exists {
@@ -274,3 +383,121 @@ func getAllPartitions(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(out)
 }
+
+func startScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	var scenario netem.Scenario
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&scenario)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = scenario.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+
+	err = netem.StartScenario(params["testnetID"], scenario, nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 409)
+		return
+	}
+	recordEvent(r, params["testnetID"], "scenario", nil, scenario)
+	w.Write([]byte("Success"))
+}
+
+func getScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	out, err := netem.GetScenarioStatus(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func abortScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	err := netem.AbortScenario(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+
+	if r.URL.Query().Get("clear") == "true" {
+		nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 404)
+			return
+		}
+		err = netem.RemoveAll(nodes)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	recordEvent(r, params["testnetID"], "scenario abort", nil,
+		map[string]bool{"clear": r.URL.Query().Get("clear") == "true"})
+	w.Write([]byte("Success"))
+}
+
+func timedPartitionOutage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	var tp netem.TimedPartition
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&tp)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = tp.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tp.TestnetID = params["testnetID"]
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	out, err := netem.StartTimedPartition(tp, nodes, getKid(r))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	recordEvent(r, params["testnetID"], "timed partition", nil, out)
+	json.NewEncoder(w).Encode(out)
+}
+
+func getTimedPartitions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	json.NewEncoder(w).Encode(netem.GetTimedPartitions(params["testnetID"]))
+}
+
+func cancelTimedPartition(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	err := netem.CancelTimedPartition(params["testnetID"], params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	recordEvent(r, params["testnetID"], "timed partition cancel", nil, map[string]string{"id": params["id"]})
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/readiness"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"strconv"
+	"strings"
+)
+
+// containerProcesses are the processes which run in a node whether or not its blockchain is running
+var containerProcesses = map[string]bool{"sh": true, "bash": true, "ps": true, "nibbler": true, "tail": true, "tee": true}
+
+// getContainerPids gets the pids of the processes running in a node, other than its shell and the
+// processes genesis runs in it. Used when the command which started the main process of a node is not known.
+func getContainerPids(client ssh.Client, node db.Node) ([]string, error) {
+	res, err := client.DockerExec(node, "ps -e -o pid=,comm=")
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, line := range strings.Split(res, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "1" || containerProcesses[fields[1]] {
+			continue
+		}
+		out = append(out, fields[0])
+	}
+	return out, nil
+}
+
+// checkReadiness checks whether a node with the given main process pids is ready, according to the
+// given probe. A node without a probe is ready as soon as its main process is running.
+func checkReadiness(client ssh.Client, node db.Node, pids []string, probe *readiness.Probe) status.Readiness {
+	out := status.Readiness{}
+	for _, pid := range pids {
+		out.ProcessAlive = out.ProcessAlive || len(strings.TrimSpace(pid)) > 0
+	}
+	if !out.ProcessAlive {
+		out.Err = fmt.Errorf("the main process of the node is not running")
+		return out
+	}
+	if probe == nil {
+		out.Ready = true
+		return out
+	}
+	res, err := client.Run(probe.Command(node.IP))
+	if err != nil && len(strings.TrimSpace(res)) > 0 {
+		err = errors.New(strings.TrimSpace(res))
+	}
+	if err != nil {
+		out.Err = fmt.Errorf("could not reach the node: %s", err.Error())
+		return out
+	}
+	out.Err = probe.Check(res)
+	out.Ready = out.Err == nil
+	return out
+}
+
+// getProber gets the prober for the nodes of the given testnet. A node is ready once its main process
+// is running and it passes the readiness probe of its blockchain, if the blockchain has one.
+func getProber(testnetID string) status.Prober {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to restore the testnet")
+	}
+	return func(client ssh.Client, node db.Node) status.Readiness {
+		var pids []string
+		err := fmt.Errorf("unknown testnet")
+		if tn != nil {
+			pids, err = helpers.GetNodePids(tn, node, strconv.Itoa(node.AbsoluteNum))
+		}
+		if err != nil {
+			// The main process is only known for the nodes started through DockerRunMainDaemon
+			pids, err = getContainerPids(client, node)
+		}
+		if err != nil {
+			return status.Readiness{Err: fmt.Errorf("could not look up the main process of the node: %s", err.Error())}
+		}
+		probe, ok := registrar.GetReadinessProbe(node.Protocol)
+		if !ok {
+			return checkReadiness(client, node, pids, nil)
+		}
+		return checkReadiness(client, node, pids, &probe)
+	}
+}
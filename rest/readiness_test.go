@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/readiness"
+	"github.com/whiteblock/genesis/ssh/mocks"
+)
+
+func TestGetContainerPids(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0, IP: "10.1.0.2"}
+	client.EXPECT().DockerExec(node, "ps -e -o pid=,comm=").Return(
+		"    1 sh\n   12 nibbler\n   40 beacon-chain\n   41 tee\n   57 ps\n", nil)
+	client.EXPECT().DockerExec(node, "ps -e -o pid=,comm=").Return("    1 sh\n   58 ps\n", nil)
+
+	pids, err := getContainerPids(client, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pids) != 1 || pids[0] != "40" {
+		t.Errorf("expected only the pid of the blockchain process, got %v", pids)
+	}
+	pids, err = getContainerPids(client, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pids) != 0 {
+		t.Errorf("expected no pids for an idle node, got %v", pids)
+	}
+}
+
+func TestCheckReadiness(t *testing.T) {
+	node := db.Node{AbsoluteNum: 0, Server: 1, LocalID: 0, IP: "10.1.0.2"}
+	probe := readiness.EthSyncing(8545)
+	var test = []struct {
+		pids   []string
+		probe  *readiness.Probe
+		output string
+		err    error
+		alive  bool
+		ready  bool
+	}{
+		{pids: []string{""}, probe: &probe, alive: false, ready: false},
+		{pids: []string{"40"}, probe: nil, alive: true, ready: true},
+		{pids: []string{"40"}, probe: &probe, output: `{"jsonrpc":"2.0","id":1,"result":false}`, alive: true, ready: true},
+		{pids: []string{"40"}, probe: &probe,
+			output: `{"jsonrpc":"2.0","id":1,"result":{"currentBlock":"0x1","highestBlock":"0x2"}}`, alive: true, ready: false},
+		{pids: []string{"40"}, probe: &probe, output: "curl: (7) Failed to connect",
+			err: fmt.Errorf("exit status 7"), alive: true, ready: false},
+	}
+	for i, tt := range test {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockClient(ctrl)
+		if tt.alive && tt.probe != nil {
+			client.EXPECT().Run(tt.probe.Command(node.IP)).Return(tt.output, tt.err)
+		}
+		res := checkReadiness(client, node, tt.pids, tt.probe)
+		if res.ProcessAlive != tt.alive || res.Ready != tt.ready {
+			t.Errorf("test %d: expected alive %v and ready %v, got %+v", i, tt.alive, tt.ready, res)
+		}
+		if !tt.ready && res.Err == nil {
+			t.Errorf("test %d: expected a reason why the node is not ready", i)
+		}
+		ctrl.Finish()
+	}
+}
@@ -21,6 +21,7 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
@@ -29,6 +30,7 @@ import (
 	"github.com/whiteblock/genesis/util"
 	"net/http"
 	"strings"
+	"time"
 )
 
 var conf *util.Config
@@ -47,17 +49,30 @@ func StartServer() {
 	router.HandleFunc("/servers/{id}", getServerInfo).Methods("GET")
 	router.HandleFunc("/servers/{id}", deleteServer).Methods("DELETE")
 	router.HandleFunc("/servers/{id}", updateServerInfo).Methods("UPDATE")
+	router.HandleFunc("/servers/{id}/health", getServerHealth).Methods("GET")
 
 	router.HandleFunc("/testnets", createTestNet).Methods("POST") //Create new test net
+	router.HandleFunc("/testnets/plan", planTestNet).Methods("POST")
 
 	router.HandleFunc("/testnets/{id}", deleteTestNet).Methods("DELETE")
 
+	router.HandleFunc("/testnets/{id}/logs/archive", getLogArchives).Methods("GET")
+	router.HandleFunc("/testnets/{id}/logs/archive", createLogArchive).Methods("POST")
+	router.HandleFunc("/testnets/{id}/logs/archive/{name}", downloadLogArchive).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/events", getEvents).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/spec", applySpec).Methods("POST")
+	router.HandleFunc("/testnets/{id}/spec/diff", diffSpec).Methods("POST")
+
 	router.HandleFunc("/testnets/{id}/nodes", getTestNetNodes).Methods("GET")
+	router.HandleFunc("/testnets/{id}/nodes/{node}/logs", getParsedLogs).Methods("GET")
 
 	/**Management Functions**/
 	router.HandleFunc("/status/nodes/{testnetID}", nodesStatus).Methods("GET")
 
 	router.HandleFunc("/status/build/{id}", buildStatus).Methods("GET")
+	router.HandleFunc("/status/build/{id}/events", buildEvents).Methods("GET")
 
 	router.HandleFunc("/params/{blockchain}", getBlockChainParams).Methods("GET")
 
@@ -65,6 +80,8 @@ func StartServer() {
 
 	router.HandleFunc("/defaults/{blockchain}", getBlockChainDefaults).Methods("GET")
 
+	router.HandleFunc("/search/log/{testnetID}", searchBlockChainLogs).Methods("GET")
+
 	router.HandleFunc("/log/{testnetID}/{node}", getBlockChainLog).Methods("GET")
 
 	router.HandleFunc("/log/{testnetID}/{node}/{lines}", getBlockChainLog).Methods("GET")
@@ -81,6 +98,17 @@ func StartServer() {
 
 	router.HandleFunc("/nodes/kill/{testnetID}/{node}", killNode).Methods("POST")
 
+	router.HandleFunc("/nodes/pause/{testnetID}/{node}", pauseNode).Methods("POST")
+	router.HandleFunc("/nodes/resume/{testnetID}/{node}", resumeNode).Methods("POST")
+
+	router.HandleFunc("/nodes/clock/{testnetID}/{node}", getClockSkew).Methods("GET")
+	router.HandleFunc("/nodes/clock/{testnetID}/{node}", setClockSkew).Methods("POST")
+
+	router.HandleFunc("/nodes/fill/{testnetID}/{node}", fillDisk).Methods("POST")
+	router.HandleFunc("/nodes/fill/{testnetID}/{node}", clearDisk).Methods("DELETE")
+
+	router.HandleFunc("/nodes/resources/{testnetID}/{node}", updateNodeResources).Methods("POST")
+
 	router.HandleFunc("/build/{id}", stopBuild).Methods("DELETE")
 
 	router.HandleFunc("/build", getPreviousBuild).Methods("GET")
@@ -100,6 +128,21 @@ func StartServer() {
 
 	router.HandleFunc("/emulate/all/{testnetID}", handleNetAll).Methods("POST")
 
+	router.HandleFunc("/emulate/links/{testnetID}", handleLinks).Methods("POST")
+
+	router.HandleFunc("/emulate/presets/bandwidth", getBandwidthPresets).Methods("GET")
+
+	router.HandleFunc("/emulate/drift/{testnetID}", getNetDrift).Methods("GET")
+	router.HandleFunc("/emulate/reconcile/{testnetID}", reconcileNet).Methods("POST")
+
+	router.HandleFunc("/emulate/scenario/{testnetID}", startScenario).Methods("POST")
+	router.HandleFunc("/emulate/scenario/{testnetID}", getScenario).Methods("GET")
+	router.HandleFunc("/emulate/scenario/{testnetID}", abortScenario).Methods("DELETE")
+
+	router.HandleFunc("/chaos/{testnetID}", startChaos).Methods("POST")
+	router.HandleFunc("/chaos/{testnetID}", getChaos).Methods("GET")
+	router.HandleFunc("/chaos/{testnetID}", stopChaos).Methods("DELETE")
+
 	router.HandleFunc("/resources/{blockchain}", getConfFiles).Methods("GET")
 
 	router.HandleFunc("/resources/{blockchain}/{file}", getConfFile).Methods("GET")
@@ -118,6 +161,10 @@ func StartServer() {
 
 	router.HandleFunc("/partition/{testnetID}", getAllPartitions).Methods("GET")
 
+	router.HandleFunc("/partition/timed/{testnetID}", timedPartitionOutage).Methods("POST")
+	router.HandleFunc("/partition/timed/{testnetID}", getTimedPartitions).Methods("GET")
+	router.HandleFunc("/partition/timed/{testnetID}/{id}", cancelTimedPartition).Methods("DELETE")
+
 	router.HandleFunc("/blockchains", getAllSupportedBlockchains).Methods("GET")
 	log.WithFields(log.Fields{"socket": conf.Listen}).Info("listening for requests")
 	log.Fatal(http.ListenAndServe(conf.Listen, removeTrailingSlash(router)))
@@ -144,7 +191,7 @@ func nodesStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out, err := status.CheckNodeStatus(nodes)
+	out, err := status.CheckNodeHealth(nodes, getProber(testnetID))
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
@@ -167,6 +214,59 @@ func buildStatus(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(res))
 }
 
+func writeBuildEvent(w http.ResponseWriter, event state.BuildEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("couldn't marshal the build event")
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+func buildEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	bs, err := state.GetBuildStateByID(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", 500)
+		return
+	}
+	events, unsubscribe := bs.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	writeBuildEvent(w, bs.Snapshot())
+	flusher.Flush()
+	if bs.Done() {
+		return
+	}
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-events:
+			writeBuildEvent(w, event)
+			if event.Type == state.EventDone {
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
 func stopBuild(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	buildID, ok := params["id"]
@@ -0,0 +1,227 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultSearchLimit is the number of matches returned in a page when no limit is given
+	defaultSearchLimit = 100
+	// maxSearchLimit is the largest number of matches which can be returned in a single page
+	maxSearchLimit = 10000
+)
+
+// timestampRegex matches the common timestamp formats found in node logs, such as
+// 2019-05-06T15:04:05.123Z, 2019-05-06 15:04:05 and [05-06|15:04:05.123]
+var timestampRegex = regexp.MustCompile(
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\d{2}-\d{2}\|\d{2}:\d{2}:\d{2}(?:\.\d+)?`)
+
+// LogMatch is a line of a node's logs which matched a search
+type LogMatch struct {
+	Node      int    `json:"node"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Text      string `json:"text"`
+}
+
+// LogSearchResult is a page of the matches of a search across the logs of a testnet
+type LogSearchResult struct {
+	Total   int        `json:"total"`
+	Offset  int        `json:"offset"`
+	Limit   int        `json:"limit"`
+	Matches []LogMatch `json:"matches"`
+}
+
+// parseGrepOutput parses the output of grep -n into matches
+func parseGrepOutput(node int, file string, res string) []LogMatch {
+	out := []LogMatch{}
+	for _, line := range strings.Split(res, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		lineNum, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		out = append(out, LogMatch{
+			Node:      node,
+			File:      file,
+			Line:      lineNum,
+			Timestamp: timestampRegex.FindString(parts[1]),
+			Text:      parts[1],
+		})
+	}
+	return out
+}
+
+// getIntQuery gets the integer value of the query parameter key, or def if it is not given
+func getIntQuery(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if len(raw) == 0 {
+		return def, nil
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("invalid %s \"%s\"", key, raw)
+	}
+	return val, nil
+}
+
+// searchNodeLogs runs grep for pattern over each of the log files of the node, keeping at most max
+// matches from each file. Also returns the total number of matches in the files.
+func searchNodeLogs(client ssh.Client, node ssh.Node, dbNode db.Node, pattern string, max int) ([]LogMatch, int, error) {
+	out := []LogMatch{}
+	total := 0
+	for _, file := range getNodeLogFiles(dbNode) {
+		//grep exits with 1 when there are no matches, and 2 on an actual error. Log files which
+		//have not been created yet are skipped. The first line is the number of matches.
+		res, err := client.DockerExec(node, fmt.Sprintf("sh -c %s", util.ShellQuote(fmt.Sprintf(
+			"[ -f %s ] || exit 0; grep -c -E -e %s %s; [ $? -lt 2 ] || exit 2; grep -n -m %d -E -e %s %s; [ $? -lt 2 ]",
+			file, util.ShellQuote(pattern), file, max, util.ShellQuote(pattern), file))))
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s %s", res, err.Error())
+		}
+		if len(res) == 0 {
+			continue
+		}
+		lines := strings.SplitN(res, "\n", 2)
+		count, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("unexpected match count \"%s\"", lines[0])
+		}
+		total += count
+		if len(lines) == 2 {
+			out = append(out, parseGrepOutput(node.GetAbsoluteNumber(), file, lines[1])...)
+		}
+	}
+	return out, total, nil
+}
+
+// searchLogs searches every node's output file and additional logs for the given
+// extended regular expression, concurrently. Only the first max matches of each file are
+// kept, which is enough to fill any page ending within the first max matches. Also returns
+// the total number of matches.
+func searchLogs(tn *testnet.TestNet, pattern string, max int) ([]LogMatch, int, error) {
+	nodes := map[int]db.Node{}
+	for _, node := range tn.Nodes {
+		nodes[node.AbsoluteNum] = node
+	}
+	mutex := sync.Mutex{}
+	out := []LogMatch{}
+	total := 0
+	err := helpers.AllNodeExecConDR(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		matches, count, err := searchNodeLogs(client, node, nodes[node.GetAbsoluteNumber()], pattern, max)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		out = append(out, matches...)
+		total += count
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	files := map[int]map[string]int{}
+	for _, node := range nodes {
+		files[node.AbsoluteNum] = map[string]int{}
+		for i, file := range getNodeLogFiles(node) {
+			files[node.AbsoluteNum][file] = i
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Node != out[j].Node {
+			return out[i].Node < out[j].Node
+		}
+		if out[i].File != out[j].File {
+			return files[out[i].Node][out[i].File] < files[out[j].Node][out[j].File]
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, total, nil
+}
+
+func searchBlockChainLogs(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	testnetID := params["testnetID"]
+	pattern := r.URL.Query().Get("pattern")
+	if len(pattern) == 0 {
+		http.Error(w, "missing the pattern to search for", 400)
+		return
+	}
+	_, err := regexp.CompilePOSIX(pattern)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	offset, err := getIntQuery(r, "offset", 0)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	limit, err := getIntQuery(r, "limit", defaultSearchLimit)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if limit == 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "pattern": pattern}).Debug("searching the logs")
+	matches, total, err := searchLogs(tn, pattern, offset+limit)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+
+	out := LogSearchResult{Total: total, Offset: offset, Limit: limit, Matches: []LogMatch{}}
+	if offset < len(matches) {
+		end := offset + limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		out.Matches = matches[offset:end]
+	}
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestSearchNodeLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	node := db.Node{AbsoluteNum: 2, Server: 1, LocalID: 0, IP: "10.1.0.2"}
+	file := getNodeLogFiles(node)[0]
+	client.EXPECT().DockerExec(node, fmt.Sprintf("sh -c %s", util.ShellQuote(fmt.Sprintf(
+		"[ -f %s ] || exit 0; grep -c -E -e 'ERROR' %s; [ $? -lt 2 ] || exit 2; grep -n -m 2 -E -e 'ERROR' %s; [ $? -lt 2 ]",
+		file, file, file)))).Return("3\n1:ERROR one\n5:2019-05-06T15:04:05Z ERROR two\n", nil)
+
+	matches, total, err := searchNodeLogs(client, node, node, "ERROR", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("expected a total of 3 matches, got %d", total)
+	}
+	if len(matches) != 2 || matches[0].Line != 1 || matches[1].Line != 5 {
+		t.Fatalf("expected the first 2 matches, got %+v", matches)
+	}
+	if matches[1].Node != 2 || matches[1].File != file || matches[1].Timestamp != "2019-05-06T15:04:05Z" {
+		t.Errorf("unexpected match %+v", matches[1])
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/inventory"
 	"github.com/whiteblock/genesis/util"
 	"net/http"
 	"strconv"
@@ -57,6 +58,7 @@ func addNewServer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	go inventory.Probe(id)
 	w.Write([]byte(strconv.Itoa(id)))
 }
 
@@ -76,6 +78,30 @@ func getServerInfo(w http.ResponseWriter, r *http.Request) {
 	util.LogError(json.NewEncoder(w).Encode(server))
 }
 
+func getServerHealth(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if len(r.URL.Query().Get("cached")) > 0 {
+		inv, ok := inventory.Get(id)
+		if !ok {
+			http.Error(w, "server has not been probed yet", 404)
+			return
+		}
+		json.NewEncoder(w).Encode(inv)
+		return
+	}
+	inv, err := inventory.Probe(id)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(inv)
+}
+
 func deleteServer(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
@@ -90,20 +116,26 @@ func deleteServer(w http.ResponseWriter, r *http.Request) {
 func updateServerInfo(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	var server db.Server
-
-	err := json.NewDecoder(r.Body).Decode(&server)
+	id, err := strconv.Atoi(params["id"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	err = server.Validate()
+
+	//Start from the stored server, so that the fields which are not given are kept
+	server, _, err := db.GetServer(id)
 	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 400)
+		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
 
-	id, err := strconv.Atoi(params["id"])
+	err = json.NewDecoder(r.Body).Decode(&server)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	server.ID = id
+	err = server.Validate()
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
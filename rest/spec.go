@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/spec"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"net/http"
+)
+
+func getSpec(r *http.Request) (spec.Spec, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return spec.Spec{}, err
+	}
+	return spec.Parse(data)
+}
+
+func diffSpec(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	desired, err := getSpec(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	out, err := spec.GetDiff(params["id"], desired)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func applySpec(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	testnetID := params["id"]
+	desired, err := getSpec(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	out, err := spec.Apply(testnetID, desired, getKid(r))
+	if err != nil && (len(out.Conflicts) > 0 || err == state.ErrBuildInProgress) {
+		http.Error(w, util.LogError(err).Error(), 409)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, testnetID, "spec apply", nil, map[string]int{"changes": len(out.Changes)})
+	json.NewEncoder(w).Encode(out)
+}
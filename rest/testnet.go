@@ -24,9 +24,9 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
 	"github.com/whiteblock/genesis/manager"
 	"github.com/whiteblock/genesis/protocols/helpers"
-	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/testnet"
@@ -70,10 +70,28 @@ func createTestNet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	go manager.AddTestNet(tn, id)
+	recordEvent(r, id, "create", nil, map[string]interface{}{"blockchain": tn.Blockchain, "nodes": tn.Nodes})
 	w.Write([]byte(id))
 
 }
 
+func planTestNet(w http.ResponseWriter, r *http.Request) {
+	tn := &db.DeploymentDetails{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(tn)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	plan, err := manager.PlanTestNet(tn)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	json.NewEncoder(w).Encode(plan)
+}
+
 func deleteTestNet(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	err := manager.DeleteTestNet(params["id"])
@@ -82,6 +100,7 @@ func deleteTestNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	recordEvent(r, params["id"], "delete", nil, nil)
 	w.Write([]byte("Success"))
 }
 
@@ -115,13 +134,17 @@ func addNodes(w http.ResponseWriter, r *http.Request) {
 		util.LogError(err)
 		//Ignore error and continue
 	}
-	bs, err := state.GetBuildStateByID(testnetID)
+	_, err = state.AcquireRebuilding(testnetID)
+	if err == state.ErrBuildInProgress {
+		http.Error(w, "There is a build in progress", 409)
+		return
+	}
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, "Testnet is down, build a new one", 409)
 		return
 	}
-	bs.Reset()
+	recordEvent(r, testnetID, "add nodes", nil, map[string]int{"nodes": tn.Nodes})
 	w.Write([]byte("Adding the nodes"))
 	go manager.AddNodes(&tn, testnetID)
 }
@@ -137,40 +160,24 @@ func delNodes(w http.ResponseWriter, r *http.Request) {
 
 	testnetID := params["id"]
 
-	tn, err := db.GetBuildByTestnet(testnetID)
+	_, err = db.GetBuildByTestnet(testnetID)
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, "Could not find the given testnet id", 400)
 		return
 	}
 
-	err = state.AcquireBuilding(tn.Servers, testnetID) //TODO: THIS IS WRONG
+	_, err = state.AcquireRebuilding(testnetID)
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, "There is a build in progress", 409)
 		return
 	}
+	recordEvent(r, testnetID, "remove nodes", nil, map[string]int{"nodes": num})
 	w.Write([]byte("Deleting the nodes"))
 	go manager.DelNodes(num, testnetID)
 }
 
-func getNodePids(tn *testnet.TestNet, n ssh.Node, node string) ([]string, error) {
-	cmdsToTry, err := helpers.GetCommandExprs(tn, node)
-	if err != nil {
-		return nil, util.LogError(err)
-	}
-	log.WithFields(log.Fields{"toTry": cmdsToTry}).Info("got the commands to try")
-	out := []string{}
-	for _, cmd := range cmdsToTry {
-		pid, err := tn.Clients[n.GetServerID()].DockerExec(n, fmt.Sprintf(
-			"ps aux | grep '%s' | grep -v grep | grep -v nibbler |  awk '{print $2}'", cmd))
-		if err == nil {
-			out = append(out, strings.Split(pid, "\n")...)
-		}
-	}
-	return out, nil
-}
-
 func restartNode(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	testnetID := params["id"]
@@ -191,51 +198,21 @@ func restartNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := status.GetClient(cmd.ServerID)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
 	node := &tn.Nodes[cmd.Node]
-	procs, err := getNodePids(tn, node, nodeNum)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-	log.WithFields(log.Fields{"procs": procs}).Debug("got the possible process ids")
-
-	for _, pid := range procs {
-		if pid == "" {
-			continue
-		}
-		_, err = client.DockerExec(node, fmt.Sprintf("kill -INT %s", pid))
+	if res, ok := tn.GetResources(node.AbsoluteNum); ok {
+		err = docker.UpdateResources(tn.Clients[node.Server], node.LocalID, res)
 		if err != nil {
 			http.Error(w, util.LogError(err).Error(), 500)
 			return
 		}
 	}
 
-	killedSuccessfully := false
-	for i := uint(0); i < conf.KillRetries; i++ {
-		_, err = client.DockerExec(node,
-			fmt.Sprintf("ps aux | grep '%s' | grep -v grep | grep -v nibbler", strings.Split(cmd.Cmdline, " ")[0]))
-		if err != nil {
-			killedSuccessfully = true
-			break
-		}
-	}
-
-	if !killedSuccessfully {
-		err := fmt.Errorf("Unable to kill the blockchain process")
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-
-	err = client.DockerExecdLogAppend(node, cmd.Cmdline)
+	err = helpers.RestartNode(tn, node)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	recordEvent(r, testnetID, "restart", []int{node.AbsoluteNum}, nil)
 	w.Write([]byte("Success"))
 }
 
@@ -254,6 +231,7 @@ func signalNode(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, fmt.Sprintf("invalid signal \"%s\", see `man 7 signal` for help", signal), 400)
+		return
 	}
 
 	tn, err := testnet.RestoreTestNet(testnetID)
@@ -266,19 +244,12 @@ func signalNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	n := &tn.Nodes[nodeNum]
-	procs, err := getNodePids(tn, tn.Nodes[nodeNum], node)
+	err = helpers.SignalNode(tn, n, signal)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
-	log.WithFields(log.Fields{"procs": procs}).Debug("got the possible process ids")
-
-	for _, pid := range procs {
-		if pid == "" {
-			continue
-		}
-		_, err = tn.Clients[n.GetServerID()].DockerExec(n, fmt.Sprintf("kill -%s %s", signal, pid))
-	}
+	recordEvent(r, testnetID, "signal", []int{n.AbsoluteNum}, map[string]string{"signal": signal})
 	w.Write([]byte(fmt.Sprintf("Sent signal %s to node %s", signal, node)))
 }
 
@@ -329,5 +300,124 @@ func killNode(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 	}
+	recordEvent(r, testnetID, "kill", []int{node.AbsoluteNum}, nil)
 	w.Write([]byte(fmt.Sprintf("Killed node %s", params["node"])))
 }
+
+// getTestnetNode restores the testnet and gets the node given in the path of the request
+func getTestnetNode(r *http.Request) (*testnet.TestNet, db.Node, error) {
+	params := mux.Vars(r)
+	nodeNum, err := strconv.Atoi(params["node"])
+	if err != nil {
+		return nil, db.Node{}, err
+	}
+	tn, err := testnet.RestoreTestNet(params["testnetID"])
+	if err != nil {
+		return nil, db.Node{}, err
+	}
+	node, err := db.GetNodeByAbsNum(tn.Nodes, nodeNum)
+	return tn, node, err
+}
+
+// setNodePaused handles both pausing and resuming a node, as they only differ in the docker command
+func setNodePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	testnetID := tn.TestNetID
+	nodeNum := node.AbsoluteNum
+	if paused && tn.IsPaused(nodeNum) {
+		http.Error(w, fmt.Sprintf("Node %d is already paused", nodeNum), 409)
+		return
+	}
+	if !paused && !tn.IsPaused(nodeNum) {
+		http.Error(w, fmt.Sprintf("Node %d is not paused", nodeNum), 409)
+		return
+	}
+
+	action := "resume"
+	fn := docker.UnpauseNode
+	if paused {
+		action = "pause"
+		fn = docker.PauseNode
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "node": nodeNum}).Infof("%s node", action)
+	err = fn(tn.Clients[node.Server], node.LocalID)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = testnet.Update(testnetID, func(tn *testnet.TestNet) error {
+		tn.SetPaused(nodeNum, paused)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, testnetID, action, []int{nodeNum}, nil)
+	w.Write([]byte("Success"))
+}
+
+func pauseNode(w http.ResponseWriter, r *http.Request) {
+	setNodePaused(w, r, true)
+}
+
+func resumeNode(w http.ResponseWriter, r *http.Request) {
+	setNodePaused(w, r, false)
+}
+
+func updateNodeResources(w http.ResponseWriter, r *http.Request) {
+	var res util.Resources
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if res.NoCPULimits() && res.NoMemoryLimits() {
+		http.Error(w, "Expected cpus or memory to be given", 400)
+		return
+	}
+	if !res.NoDiskLimits() || len(res.Volumes) > 0 || len(res.Ports) > 0 {
+		http.Error(w, "Only cpus and memory can be changed on a running node", 400)
+		return
+	}
+	err = res.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+
+	tn, node, err := getTestnetNode(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": node.AbsoluteNum, "resources": res}).Info("updating the resources of a node")
+	err = docker.UpdateResources(tn.Clients[node.Server], node.LocalID, res)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = testnet.Update(tn.TestNetID, func(tn *testnet.TestNet) error {
+		//Keep the limit which wasn't changed from the last update
+		if prev, ok := tn.GetResources(node.AbsoluteNum); ok {
+			if res.NoCPULimits() {
+				res.Cpus = prev.Cpus
+			}
+			if res.NoMemoryLimits() {
+				res.Memory = prev.Memory
+			}
+		}
+		tn.SetResources(node.AbsoluteNum, res)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	recordEvent(r, tn.TestNetID, "resources", []int{node.AbsoluteNum}, res)
+	w.Write([]byte("Success"))
+}
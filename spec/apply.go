@@ -0,0 +1,264 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package spec
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/manager"
+	netconf "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+)
+
+// getDeployment gets the deployment details which the given node was built from
+func getDeployment(tn *testnet.TestNet, node int) db.DeploymentDetails {
+	first := 0
+	for _, details := range tn.Details {
+		if node < first+details.Nodes {
+			return details
+		}
+		first += details.Nodes
+	}
+	return *tn.LDD
+}
+
+// GetState reads the current state of a testnet
+func GetState(tn *testnet.TestNet) (State, error) {
+	out := State{
+		Blockchain:   tn.Details[0].Blockchain,
+		Servers:      tn.Details[0].Servers,
+		Nodes:        len(tn.Nodes),
+		Images:       make([]string, len(tn.Nodes)),
+		Params:       tn.CombinedDetails.Params,
+		Environments: make([]map[string]string, len(tn.Nodes)),
+		Resources:    make([]util.Resources, len(tn.Nodes)),
+		ClockSkew:    make([]float64, len(tn.Nodes)),
+		Skewed:       make([]bool, len(tn.Nodes)),
+		Netem:        netconf.GetIntent(tn.TestNetID),
+	}
+	//The per node values of each deployment are indexed by absolute node number, see deploy.Build
+	for i, node := range tn.Nodes {
+		out.Images[i] = node.Image
+		details := getDeployment(tn, node.AbsoluteNum)
+		if len(details.Environments) > i {
+			out.Environments[i] = details.Environments[i]
+		}
+		if len(details.Resources) > i {
+			out.Resources[i] = details.Resources[i]
+		} else if len(details.Resources) > 0 {
+			out.Resources[i] = details.Resources[0]
+		}
+		if res, ok := tn.GetResources(i); ok {
+			out.Resources[i].Cpus = res.Cpus
+			out.Resources[i].Memory = res.Memory
+		}
+	}
+
+	mux := sync.Mutex{}
+	err := helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		offset, skewed, err := docker.GetClockSkew(client, node)
+		if err != nil {
+			return util.LogError(err)
+		}
+		mux.Lock()
+		defer mux.Unlock()
+		out.ClockSkew[node.GetAbsoluteNumber()] = offset
+		out.Skewed[node.GetAbsoluteNumber()] = skewed
+		return nil
+	})
+	if err != nil {
+		return State{}, util.LogError(err)
+	}
+	out.Outages, err = netconf.GetAllCutConnections(tn.Nodes)
+	return out, util.LogError(err)
+}
+
+// GetDiff finds the changes needed to converge the given testnet onto the spec
+func GetDiff(testnetID string, spec Spec) (Diff, error) {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return Diff{}, err
+	}
+	current, err := GetState(tn)
+	if err != nil {
+		return Diff{}, err
+	}
+	return diff(spec, current), nil
+}
+
+// getAddedDetails gets the deployment details to add the new nodes of the spec with. Like when adding nodes
+// through the API, what the spec leaves out is filled in from the original build. The per node values are
+// indexed by absolute node number, so only the clock skew, which is indexed by the new nodes, needs adjusting.
+func getAddedDetails(original db.DeploymentDetails, spec Spec, change Change) db.DeploymentDetails {
+	out := original
+	out.Nodes = change.Count
+	if len(spec.Blockchain) > 0 {
+		out.Blockchain = spec.Blockchain
+	}
+	if len(spec.Servers) > 0 {
+		out.Servers = spec.Servers
+	}
+	if len(spec.Images) > 0 {
+		out.Images = spec.Images
+	}
+	if spec.Params != nil {
+		out.Params = spec.Params
+	}
+	if spec.Resources != nil {
+		out.Resources = spec.Resources
+	}
+	if spec.Environments != nil {
+		out.Environments = spec.Environments
+	}
+	if spec.Files != nil {
+		out.Files = spec.Files
+	}
+	if spec.Logs != nil {
+		out.Logs = spec.Logs
+	}
+
+	extras := map[string]interface{}{}
+	for key, value := range out.Extras {
+		extras[key] = value
+	}
+	for key, value := range spec.Extras {
+		extras[key] = value
+	}
+	delete(extras, "clockSkew")
+	offsets, _ := deploy.GetClockSkew(&spec.DeploymentDetails)
+	if offsets != nil {
+		added := []interface{}{}
+		for _, node := range change.Nodes {
+			added = append(added, offsets[node])
+		}
+		extras["clockSkew"] = added
+	}
+	out.Extras = extras
+	return out
+}
+
+// applyChange takes a single step towards the spec
+func applyChange(testnetID string, spec Spec, change Change) error {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return err
+	}
+	if change.Type == ChangeRemoveNodes {
+		err = manager.DelNodesFromTestNet(change.Count, tn)
+		tn.FinishedStep()
+		return err
+	}
+	if change.Type == ChangeAddNodes {
+		original, err := db.GetBuildByTestnet(testnetID)
+		if err != nil {
+			return err
+		}
+		details := getAddedDetails(original, spec, change)
+		err = manager.AddNodesToTestNet(&details, tn)
+		tn.FinishedStep()
+		return err
+	}
+	nodes := []db.Node{}
+	for _, num := range change.Nodes {
+		node, err := db.GetNodeByAbsNum(tn.Nodes, num)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+
+	switch change.Type {
+	case ChangeResources:
+		err = docker.UpdateResources(tn.Clients[nodes[0].Server], nodes[0].LocalID, *change.Resources)
+		if err != nil {
+			return err
+		}
+		err = testnet.Update(testnetID, func(tn *testnet.TestNet) error {
+			tn.SetResources(nodes[0].AbsoluteNum, *change.Resources)
+			return nil
+		})
+	case ChangeClockSkew:
+		err = docker.SetClockSkew(tn.Clients[nodes[0].Server], nodes[0], *change.Offset)
+	case ChangeRestart:
+		err = helpers.RestartNode(tn, nodes[0])
+	case ChangeRemoveNetem:
+		err = netconf.RemoveAll(nodes)
+	case ChangeNetem:
+		err = netconf.ApplyToAll(*change.Netconf, nodes)
+	case ChangeRemoveOutage:
+		err = netconf.RemoveOneWayOutage(nodes[0], nodes[1])
+	case ChangeOutage:
+		err = netconf.MakeOneWayOutage(nodes[0], nodes[1])
+	default:
+		err = fmt.Errorf("unknown change \"%s\"", change.Type)
+	}
+	return err
+}
+
+// Apply converges the given testnet onto the spec in the background. The progress and any
+// errors are reported through the build state of the testnet, and each change is recorded in the
+// event journal under the given kid. Returns the changes being made, or an error if the spec
+// conflicts with the testnet. Gives state.ErrBuildInProgress if the testnet is already being built.
+func Apply(testnetID string, spec Spec, kid string) (Diff, error) {
+	//The diff is taken under the lock, so that it can't go stale before it is applied
+	bs, err := state.AcquireRebuilding(testnetID)
+	if err != nil {
+		return Diff{}, err
+	}
+	changes, err := GetDiff(testnetID, spec)
+	if err != nil {
+		bs.DoneBuilding()
+		return Diff{}, err
+	}
+	if len(changes.Conflicts) > 0 {
+		bs.DoneBuilding()
+		return changes, fmt.Errorf("the spec conflicts with the testnet: %v", changes.Conflicts)
+	}
+	go func() {
+		defer bs.DoneBuilding()
+		for i, change := range changes.Changes {
+			bs.SetBuildStage(fmt.Sprintf("Applying change %d of %d: %s", i+1, len(changes.Changes), change.Type))
+			log.WithFields(log.Fields{"testnet": testnetID, "change": change}).Info("applying a change from a spec")
+			err := applyChange(testnetID, spec, change)
+			if err != nil {
+				bs.ReportError(err)
+				return
+			}
+			_, err = db.InsertEvent(db.Event{
+				TestNetID: testnetID,
+				Kid:       kid,
+				Action:    "spec " + change.Type,
+				Nodes:     change.Nodes,
+				Details:   change,
+			})
+			if err != nil {
+				log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to record an event")
+			}
+		}
+	}()
+	return changes, nil
+}
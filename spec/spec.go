@@ -0,0 +1,345 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package spec handles declarative testnet specs, which describe the desired state of a testnet, and
+// converging a running testnet onto them
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	netconf "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/util"
+	"reflect"
+	"sort"
+)
+
+const (
+	// ChangeRemoveNodes removes nodes from the end of the testnet
+	ChangeRemoveNodes = "remove nodes"
+	// ChangeResources changes the cpu and memory limits of a node
+	ChangeResources = "resources"
+	// ChangeClockSkew changes the clock offset of a node
+	ChangeClockSkew = "clock skew"
+	// ChangeRestart restarts the main process of a node
+	ChangeRestart = "restart"
+	// ChangeAddNodes adds nodes to the end of the testnet
+	ChangeAddNodes = "add nodes"
+	// ChangeRemoveNetem removes the network conditions from nodes
+	ChangeRemoveNetem = "remove netem"
+	// ChangeNetem applies network conditions to a node
+	ChangeNetem = "netem"
+	// ChangeRemoveOutage allows the first node to send to the second node again
+	ChangeRemoveOutage = "remove outage"
+	// ChangeOutage prevents the first node from sending to the second node
+	ChangeOutage = "outage"
+)
+
+// Spec is the desired state of a testnet. The deployment details have the same meaning as they do
+// for a build, and are used as is when nodes are added.
+type Spec struct {
+	db.DeploymentDetails
+	// Netem is the network conditions of each node, by absolute node number. Nodes which are not
+	// given have no network conditions.
+	Netem map[int]netconf.Netconf `json:"netem,omitempty"`
+	// Outages are the pairs of nodes which cannot connect to each other
+	Outages [][2]int `json:"outages,omitempty"`
+}
+
+// Change is a single step needed to converge a testnet onto a spec
+type Change struct {
+	Type string `json:"type"`
+	// Nodes are the absolute numbers of the nodes changed. For outages, the traffic from the
+	// first node to the second node is changed.
+	Nodes     []int            `json:"nodes"`
+	Count     int              `json:"count,omitempty"`
+	Resources *util.Resources  `json:"resources,omitempty"`
+	Offset    *float64         `json:"offset,omitempty"`
+	Netconf   *netconf.Netconf `json:"netconf,omitempty"`
+}
+
+// Diff is the difference between a spec and a running testnet
+type Diff struct {
+	// Conflicts are the differences which cannot be applied to a running testnet, they need a new build
+	Conflicts []string `json:"conflicts"`
+	// Changes are the steps to take, in order
+	Changes []Change `json:"changes"`
+}
+
+// State is the current state of a testnet, in the terms of a spec. The values for each node are indexed
+// by absolute node number.
+type State struct {
+	Blockchain   string
+	Servers      []int
+	Nodes        int
+	Images       []string
+	Params       map[string]interface{}
+	Environments []map[string]string
+	Resources    []util.Resources
+	ClockSkew    []float64
+	// Skewed is whether the main process of each node was started under libfaketime
+	Skewed  []bool
+	Netem   map[int]netconf.Netconf
+	Outages []netconf.Connection
+}
+
+// Parse parses a spec given as JSON, and validates it
+func Parse(data []byte) (Spec, error) {
+	var out Spec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&out)
+	if err != nil {
+		return Spec{}, err
+	}
+	return out, out.Validate()
+}
+
+// Validate checks that the spec is valid
+func (spec Spec) Validate() error {
+	if spec.Nodes < 1 {
+		return fmt.Errorf("a testnet needs at least one node")
+	}
+	for i, res := range spec.Resources {
+		err := res.Validate()
+		if err != nil {
+			return fmt.Errorf("%s. For node %d", err.Error(), i)
+		}
+	}
+	_, err := deploy.GetClockSkew(&spec.DeploymentDetails)
+	if err != nil {
+		return err
+	}
+	for node, nconf := range spec.Netem {
+		if node < 0 || node >= spec.Nodes {
+			return fmt.Errorf("netem given for node %d, which is not in the spec", node)
+		}
+		err = nconf.Validate()
+		if err != nil {
+			return fmt.Errorf("node %d: %s", node, err.Error())
+		}
+	}
+	for _, outage := range spec.Outages {
+		for _, node := range outage {
+			if node < 0 || node >= spec.Nodes {
+				return fmt.Errorf("outage given for node %d, which is not in the spec", node)
+			}
+		}
+		if outage[0] == outage[1] {
+			return fmt.Errorf("node %d cannot have an outage with itself", outage[0])
+		}
+	}
+	return nil
+}
+
+// getResources gets the resources the spec gives node, false if it doesn't give any
+func (spec Spec) getResources(node int) (util.Resources, bool) {
+	if len(spec.Resources) > node {
+		return spec.Resources[node], true
+	}
+	if len(spec.Resources) > 0 {
+		return spec.Resources[0], true
+	}
+	return util.Resources{}, false
+}
+
+// normalize gets the value as it would be after a round trip through json, so that
+// values which came from different places can be compared
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if decoder.Decode(&out) != nil {
+		return value
+	}
+	return out
+}
+
+func sameMemory(mem1 string, mem2 string) bool {
+	m1, err1 := util.ParseSize(mem1)
+	m2, err2 := util.ParseSize(mem2)
+	if err1 != nil || err2 != nil {
+		return mem1 == mem2
+	}
+	return m1 == m2
+}
+
+func sameServers(servers1 []int, servers2 []int) bool {
+	s1 := append([]int{}, servers1...)
+	s2 := append([]int{}, servers2...)
+	sort.Ints(s1)
+	sort.Ints(s2)
+	return reflect.DeepEqual(s1, s2)
+}
+
+// diffNode finds the differences in the setup of an existing node
+func diffNode(spec Spec, offsets []float64, state State, node int, out *Diff) {
+	if len(spec.Images) > 0 {
+		image := spec.Images[0]
+		if len(spec.Images) > node {
+			image = spec.Images[node]
+		}
+		if image != state.Images[node] {
+			out.Conflicts = append(out.Conflicts, fmt.Sprintf("node %d: changing the image from %s to %s needs a new build",
+				node, state.Images[node], image))
+		}
+	}
+	if spec.Environments != nil {
+		var env map[string]string
+		if len(spec.Environments) > node {
+			env = spec.Environments[node]
+		}
+		if len(env) != len(state.Environments[node]) || (len(env) > 0 && !reflect.DeepEqual(env, state.Environments[node])) {
+			out.Conflicts = append(out.Conflicts, fmt.Sprintf("node %d: changing the environment needs a new build", node))
+		}
+	}
+
+	if res, ok := spec.getResources(node); ok {
+		current := state.Resources[node]
+		fixed, currentFixed := res, current
+		fixed.Cpus, fixed.Memory, currentFixed.Cpus, currentFixed.Memory = "", "", "", ""
+		if !reflect.DeepEqual(normalize(fixed), normalize(currentFixed)) {
+			out.Conflicts = append(out.Conflicts, fmt.Sprintf("node %d: only cpus and memory can be changed without a new build", node))
+		} else if res.Cpus != current.Cpus || !sameMemory(res.Memory, current.Memory) {
+			update := util.Resources{Cpus: res.Cpus, Memory: res.Memory}
+			out.Changes = append(out.Changes, Change{Type: ChangeResources, Nodes: []int{node}, Resources: &update})
+		}
+	}
+
+	offset := 0.0
+	if offsets != nil {
+		offset = offsets[node]
+	}
+	if offset != state.ClockSkew[node] {
+		out.Changes = append(out.Changes, Change{Type: ChangeClockSkew, Nodes: []int{node}, Offset: &offset})
+		if !state.Skewed[node] {
+			out.Changes = append(out.Changes, Change{Type: ChangeRestart, Nodes: []int{node}})
+		}
+	}
+}
+
+// diff finds the changes needed to get from the state to the spec
+func diff(spec Spec, state State) Diff {
+	out := Diff{Conflicts: []string{}, Changes: []Change{}}
+	if len(spec.Blockchain) > 0 && spec.Blockchain != state.Blockchain {
+		out.Conflicts = append(out.Conflicts, fmt.Sprintf("changing the blockchain from %s to %s needs a new build",
+			state.Blockchain, spec.Blockchain))
+	}
+	if len(spec.Servers) > 0 && !sameServers(spec.Servers, state.Servers) {
+		out.Conflicts = append(out.Conflicts, "changing the servers needs a new build")
+	}
+	if spec.Params != nil && !reflect.DeepEqual(normalize(spec.Params), normalize(state.Params)) {
+		out.Conflicts = append(out.Conflicts, "changing the params needs a new build")
+	}
+
+	if spec.Nodes < state.Nodes {
+		removed := []int{}
+		for node := spec.Nodes; node < state.Nodes; node++ {
+			removed = append(removed, node)
+		}
+		out.Changes = append(out.Changes, Change{Type: ChangeRemoveNodes, Nodes: removed, Count: len(removed)})
+	}
+	//Already validated
+	offsets, _ := deploy.GetClockSkew(&spec.DeploymentDetails)
+	existing := state.Nodes
+	if spec.Nodes < existing {
+		existing = spec.Nodes
+	}
+	for node := 0; node < existing; node++ {
+		diffNode(spec, offsets, state, node, &out)
+	}
+	if spec.Nodes > state.Nodes {
+		added := []int{}
+		for node := state.Nodes; node < spec.Nodes; node++ {
+			added = append(added, node)
+		}
+		out.Changes = append(out.Changes, Change{Type: ChangeAddNodes, Nodes: added, Count: len(added)})
+	}
+
+	diffNetwork(spec, state, existing, &out)
+	return out
+}
+
+// diffNetwork finds the changes to the network conditions and outages. Only the first existing nodes
+// are already built, the rest are added by the earlier changes.
+func diffNetwork(spec Spec, state State, existing int, out *Diff) {
+	removed := []int{}
+	netems := []Change{}
+	for node := 0; node < spec.Nodes; node++ {
+		want, wanted := spec.Netem[node]
+		have, had := state.Netem[node]
+		if node >= existing {
+			had = false
+		}
+		want.Node = 0
+		have.Node = 0
+		switch {
+		case wanted && (!had || !reflect.DeepEqual(want, have)):
+			nconf := want
+			netems = append(netems, Change{Type: ChangeNetem, Nodes: []int{node}, Netconf: &nconf})
+		case !wanted && had:
+			removed = append(removed, node)
+		}
+	}
+	if len(removed) > 0 {
+		out.Changes = append(out.Changes, Change{Type: ChangeRemoveNetem, Nodes: removed})
+	}
+	out.Changes = append(out.Changes, netems...)
+
+	wanted := map[[2]int]bool{}
+	for _, outage := range spec.Outages {
+		wanted[outage] = true
+		wanted[[2]int{outage[1], outage[0]}] = true
+	}
+	had := map[[2]int]bool{}
+	for _, conn := range state.Outages {
+		if conn.From < existing && conn.To < existing {
+			had[[2]int{conn.From, conn.To}] = true
+		}
+	}
+	heals := [][2]int{}
+	for cut := range had {
+		if !wanted[cut] {
+			heals = append(heals, cut)
+		}
+	}
+	cuts := [][2]int{}
+	for cut := range wanted {
+		if !had[cut] {
+			cuts = append(cuts, cut)
+		}
+	}
+	for _, links := range [][][2]int{heals, cuts} {
+		sort.Slice(links, func(i, j int) bool {
+			return links[i][0] < links[j][0] || (links[i][0] == links[j][0] && links[i][1] < links[j][1])
+		})
+	}
+	for _, heal := range heals {
+		out.Changes = append(out.Changes, Change{Type: ChangeRemoveOutage, Nodes: []int{heal[0], heal[1]}})
+	}
+	for _, cut := range cuts {
+		out.Changes = append(out.Changes, Change{Type: ChangeOutage, Nodes: []int{cut[0], cut[1]}})
+	}
+}
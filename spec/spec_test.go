@@ -0,0 +1,193 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package spec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	netconf "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+)
+
+func testState(nodes int) State {
+	out := State{
+		Blockchain:   "lighthouse",
+		Servers:      []int{1},
+		Nodes:        nodes,
+		Images:       make([]string, nodes),
+		Environments: make([]map[string]string, nodes),
+		Resources:    make([]util.Resources, nodes),
+		ClockSkew:    make([]float64, nodes),
+		Skewed:       make([]bool, nodes),
+		Netem:        map[int]netconf.Netconf{},
+		Outages:      []netconf.Connection{},
+	}
+	for i := range out.Images {
+		out.Images[i] = "lighthouse:latest"
+	}
+	return out
+}
+
+func getTypes(diff Diff) []string {
+	out := []string{}
+	for _, change := range diff.Changes {
+		out = append(out, change.Type)
+	}
+	return out
+}
+
+func TestDiff_Nodes(t *testing.T) {
+	spec := Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 3}}
+	res := diff(spec, testState(3))
+	if len(res.Changes) != 0 || len(res.Conflicts) != 0 {
+		t.Errorf("expected no differences, got %v", res)
+	}
+
+	res = diff(spec, testState(5))
+	expected := []Change{{Type: ChangeRemoveNodes, Nodes: []int{3, 4}, Count: 2}}
+	if !reflect.DeepEqual(res.Changes, expected) {
+		t.Errorf("expected %v, got %v", expected, res.Changes)
+	}
+
+	spec.Nodes = 4
+	res = diff(spec, testState(2))
+	expected = []Change{{Type: ChangeAddNodes, Nodes: []int{2, 3}, Count: 2}}
+	if !reflect.DeepEqual(res.Changes, expected) {
+		t.Errorf("expected %v, got %v", expected, res.Changes)
+	}
+}
+
+func TestDiff_Conflicts(t *testing.T) {
+	spec := Spec{DeploymentDetails: db.DeploymentDetails{
+		Nodes:      2,
+		Blockchain: "prysm",
+		Images:     []string{"lighthouse:latest", "lighthouse:next"},
+		Resources:  []util.Resources{{Cpus: "1", ReadBps: "10mb"}},
+	}}
+	res := diff(spec, testState(2))
+	if len(res.Conflicts) != 4 {
+		t.Errorf("expected conflicts for the blockchain, the image of node 1 and the disk limits of both nodes, got %v",
+			res.Conflicts)
+	}
+}
+
+func TestDiff_Nodes_Changes(t *testing.T) {
+	spec := Spec{DeploymentDetails: db.DeploymentDetails{
+		Nodes:     3,
+		Resources: []util.Resources{{Cpus: "1", Memory: "1gb"}, {Cpus: "2", Memory: "1000mb"}},
+		Extras:    map[string]interface{}{"clockSkew": []interface{}{0.0, 0.0, 2.0}},
+	}}
+	state := testState(3)
+	for i := range state.Resources {
+		state.Resources[i] = util.Resources{Cpus: "1", Memory: "1gb"}
+	}
+	state.ClockSkew[0] = 1
+	state.Skewed[0] = true
+
+	res := diff(spec, state)
+	expected := []string{ChangeClockSkew, ChangeResources, ChangeClockSkew, ChangeRestart}
+	if !reflect.DeepEqual(getTypes(res), expected) {
+		t.Errorf("expected changes %v, got %v", expected, res.Changes)
+	}
+	if res.Changes[1].Nodes[0] != 1 || res.Changes[1].Resources.Cpus != "2" {
+		t.Errorf("expected the cpus of node 1 to be changed, got %v", res.Changes[1])
+	}
+}
+
+func TestDiff_Network(t *testing.T) {
+	spec := Spec{
+		DeploymentDetails: db.DeploymentDetails{Nodes: 4},
+		Netem: map[int]netconf.Netconf{
+			0: {Delay: 1000},
+			1: {Loss: 5},
+			3: {Delay: 2000},
+		},
+		Outages: [][2]int{{0, 1}, {1, 3}},
+	}
+	state := testState(3)
+	state.Netem[0] = netconf.Netconf{Node: 5, Delay: 1000}
+	state.Netem[1] = netconf.Netconf{Loss: 10}
+	state.Netem[2] = netconf.Netconf{Loss: 10}
+	state.Outages = []netconf.Connection{{From: 0, To: 1}, {From: 1, To: 0}, {From: 2, To: 0}}
+
+	res := diff(spec, state)
+	expected := []Change{
+		{Type: ChangeAddNodes, Nodes: []int{3}, Count: 1},
+		{Type: ChangeRemoveNetem, Nodes: []int{2}},
+		{Type: ChangeNetem, Nodes: []int{1}, Netconf: &netconf.Netconf{Loss: 5}},
+		{Type: ChangeNetem, Nodes: []int{3}, Netconf: &netconf.Netconf{Delay: 2000}},
+		{Type: ChangeRemoveOutage, Nodes: []int{2, 0}},
+		{Type: ChangeOutage, Nodes: []int{1, 3}},
+		{Type: ChangeOutage, Nodes: []int{3, 1}},
+	}
+	if !reflect.DeepEqual(res.Changes, expected) {
+		t.Errorf("expected %v, got %v", expected, res.Changes)
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	var test = []struct {
+		spec  Spec
+		valid bool
+	}{
+		{spec: Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 2}}, valid: true},
+		{spec: Spec{}, valid: false},
+		{spec: Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 2}, Netem: map[int]netconf.Netconf{2: {}}}, valid: false},
+		{spec: Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 2}, Outages: [][2]int{{1, 1}}}, valid: false},
+		{spec: Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 2}, Outages: [][2]int{{0, 2}}}, valid: false},
+		{spec: Spec{DeploymentDetails: db.DeploymentDetails{Nodes: 2,
+			Extras: map[string]interface{}{"clockSkew": []interface{}{1.0}}}}, valid: false},
+	}
+	for i, tt := range test {
+		err := tt.spec.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%d: expected valid to be %v, got error %v", i, tt.valid, err)
+		}
+	}
+}
+
+func TestApply_ReleasesLock(t *testing.T) {
+	id, err := util.GetUUIDString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = state.AcquireBuilding([]int{}, id); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := state.GetBuildStateByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs.DoneBuilding()
+
+	//There is no testnet to take the diff of
+	_, err = Apply(id, Spec{}, "")
+	if err == nil {
+		t.Fatal("expected the diff to fail")
+	}
+	if !bs.Done() {
+		t.Error("expected the lock to be released after the diff failed")
+	}
+	if _, err = state.AcquireRebuilding(id); err != nil {
+		t.Errorf("expected to be able to rebuild after the failed apply, got %v", err)
+	}
+}
@@ -21,6 +21,7 @@
 package ssh
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
@@ -29,6 +30,7 @@ import (
 	"github.com/whiteblock/scp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/semaphore"
+	"io"
 	"io/ioutil"
 	"strings"
 	"sync"
@@ -37,6 +39,14 @@ import (
 
 var conf = util.GetConfig()
 
+const (
+	// FaketimeLibrary is where libfaketime is placed inside of a node with a skewed clock
+	FaketimeLibrary = "/libfaketime.so.1"
+	// FaketimeFile holds the clock offset of a node with a skewed clock. The main process of a node is
+	// only started with libfaketime if this file exists.
+	FaketimeFile = "/faketime"
+)
+
 // Client maintains a persistent connect with a server,
 // allowing commands to be run on that server. This object is thread safe.
 type Client interface {
@@ -93,6 +103,14 @@ type Client interface {
 	// it will return the last `lines` lines of the file
 	DockerRead(node Node, file string, lines int) (string, error)
 
+	// DockerFollow streams the lines appended to the given files on a node into out, starting with the
+	// last `lines` lines of each file if lines > -1. Blocks until ctx is done or the stream ends.
+	DockerFollow(ctx context.Context, node Node, files []string, lines int, out io.Writer) error
+
+	// DockerCopyOut streams a file out of a node into out, as a tar archive holding just that file.
+	// The file is never held in memory as a whole.
+	DockerCopyOut(node Node, file string, out io.Writer) error
+
 	// DockerMultiExec will run all of the given commands strung together with && on
 	// the given node.
 	DockerMultiExec(node Node, commands []string) (string, error)
@@ -306,11 +324,18 @@ func (sshClient *client) DockerRunMainDaemon(node Node, command string) error {
 	return sshClient.DockerExecdLog(node, command)
 }
 
+// withClockSkew preloads libfaketime for the given command, if the clock of the node is skewed.
+// The offset file is re-read every second, so the offset can be changed while the process runs.
+func withClockSkew(command string) string {
+	return fmt.Sprintf("[ -f %s ] && export LD_PRELOAD=%s FAKETIME_TIMESTAMP_FILE=%s FAKETIME_CACHE_DURATION=1; %s",
+		FaketimeFile, FaketimeLibrary, FaketimeFile, command)
+}
+
 // DockerExecdLog will cause the stdout and stderr of the command to be stored in the logs.
 // Should only be used for the blockchain process.
 func (sshClient *client) DockerExecdLog(node Node, command string) error {
 	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 > %s'", node.GetNodeName(),
-		command, conf.DockerOutputFile))
+		withClockSkew(command), conf.DockerOutputFile))
 	return util.LogError(err)
 }
 
@@ -318,7 +343,7 @@ func (sshClient *client) DockerExecdLog(node Node, command string) error {
 // Should only be used for the blockchain process. Will append to existing logs.
 func (sshClient *client) DockerExecdLogAppend(node Node, command string) error {
 	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 >> %s'", node.GetNodeName(),
-		command, conf.DockerOutputFile))
+		withClockSkew(command), conf.DockerOutputFile))
 	return util.LogError(err)
 }
 
@@ -331,6 +356,71 @@ func (sshClient *client) DockerRead(node Node, file string, lines int) (string,
 	return sshClient.DockerExec(node, fmt.Sprintf("cat %s", file))
 }
 
+// DockerFollow streams the lines appended to the given files on a node into out, starting with the
+// last `lines` lines of each file if lines > -1. Blocks until ctx is done or the stream ends.
+func (sshClient *client) DockerFollow(ctx context.Context, node Node, files []string, lines int, out io.Writer) error {
+	session, err := sshClient.getSession()
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer session.Close()
+
+	cmd := fmt.Sprintf("docker exec %s tail -F", node.GetNodeName())
+	if lines > -1 {
+		cmd += fmt.Sprintf(" -n %d", lines)
+	}
+	cmd += " " + strings.Join(files, " ")
+	log.WithFields(log.Fields{"host": sshClient.host, "command": cmd}).Debug("following files")
+
+	stderr := &bytes.Buffer{}
+	session.Get().Stdout = out
+	session.Get().Stderr = stderr
+	err = session.Get().Start(cmd)
+	if err != nil {
+		return util.LogError(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Get().Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		//The remote tail exits on its next write once the session is closed
+		session.Get().Signal(ssh.SIGTERM)
+		session.Get().Close()
+		<-done //Wait for the output to stop being copied into out
+		return nil
+	case err = <-done:
+		if err != nil {
+			return util.FormatError(stderr.String(), err)
+		}
+		return nil
+	}
+}
+
+// DockerCopyOut streams a file out of a node into out, as a tar archive holding just that file.
+// The file is never held in memory as a whole.
+func (sshClient *client) DockerCopyOut(node Node, file string, out io.Writer) error {
+	session, err := sshClient.getSession()
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer session.Close()
+
+	cmd := fmt.Sprintf("docker cp -L %s:%s -", node.GetNodeName(), file)
+	log.WithFields(log.Fields{"host": sshClient.host, "command": cmd}).Debug("copying a file out of a node")
+
+	stderr := &bytes.Buffer{}
+	session.Get().Stdout = out
+	session.Get().Stderr = stderr
+	err = session.Get().Run(cmd)
+	if err != nil {
+		return util.FormatError(stderr.String(), err)
+	}
+	return nil
+}
+
 func (sshClient *client) dockerMultiExec(node Node, commands []string, kt bool) (string, error) {
 	mergedCommand := ""
 
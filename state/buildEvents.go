@@ -0,0 +1,137 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	// EventStatus is a snapshot of the whole build state, sent when subscribing
+	EventStatus = "status"
+	// EventStage is sent when the build stage changes
+	EventStage = "stage"
+	// EventProgress is sent when the build progresses
+	EventProgress = "progress"
+	// EventError is sent when an error is reported
+	EventError = "error"
+	// EventFreeze is sent when the build is frozen
+	EventFreeze = "freeze"
+	// EventThaw is sent when the build is unfrozen
+	EventThaw = "thaw"
+	// EventDone is sent when the build finishes, it is the last event sent for a build
+	EventDone = "done"
+)
+
+// subscriberBuffer is the number of events which can queue up for a subscriber before
+// further events are dropped for it. EventDone is never dropped.
+const subscriberBuffer = 256
+
+// BuildEvent is a change in the state of a build
+type BuildEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Stage    string    `json:"stage,omitempty"`
+	Progress float64   `json:"progress"`
+	Error    string    `json:"error,omitempty"`
+	Frozen   bool      `json:"frozen"`
+}
+
+type buildSubscribers struct {
+	mux  sync.Mutex
+	next int
+	subs map[int]chan BuildEvent
+}
+
+func newBuildSubscribers() *buildSubscribers {
+	return &buildSubscribers{subs: map[int]chan BuildEvent{}}
+}
+
+// Subscribe gets a channel which receives every event of the build from now on, along with the
+// function which stops the subscription. The channel is not closed when the build finishes, an
+// EventDone is sent instead, which is delivered even if the subscriber has fallen behind.
+func (bs *BuildState) Subscribe() (<-chan BuildEvent, func()) {
+	bs.subscribers.mux.Lock()
+	defer bs.subscribers.mux.Unlock()
+	id := bs.subscribers.next
+	bs.subscribers.next++
+	ch := make(chan BuildEvent, subscriberBuffer)
+	bs.subscribers.subs[id] = ch
+	return ch, func() {
+		bs.subscribers.mux.Lock()
+		defer bs.subscribers.mux.Unlock()
+		delete(bs.subscribers.subs, id)
+	}
+}
+
+// Snapshot creates an EventStatus with the current state of the build
+func (bs *BuildState) Snapshot() BuildEvent {
+	bs.mutex.RLock()
+	stage := bs.BuildStage
+	bs.mutex.RUnlock()
+	return bs.newEvent(EventStatus, stage)
+}
+
+func (bs *BuildState) newEvent(eventType string, stage string) BuildEvent {
+	event := BuildEvent{
+		Type:     eventType,
+		Time:     time.Now(),
+		Stage:    stage,
+		Progress: bs.GetProgress(),
+		Frozen:   bs.IsFrozen(),
+	}
+	bs.errMutex.RLock()
+	event.Error = bs.BuildError.What
+	bs.errMutex.RUnlock()
+	return event
+}
+
+// emit sends an event to all of the subscribers, without waiting on slow subscribers. The events which
+// don't fit in the buffer of a subscriber are dropped, except for EventDone, which takes the place of the
+// oldest queued event instead. The caller must not hold errMutex.
+func (bs *BuildState) emit(eventType string, stage string) {
+	if bs.subscribers == nil {
+		return
+	}
+	bs.subscribers.mux.Lock()
+	defer bs.subscribers.mux.Unlock()
+	if len(bs.subscribers.subs) == 0 {
+		return
+	}
+	event := bs.newEvent(eventType, stage)
+	for id, ch := range bs.subscribers.subs {
+		select {
+		case ch <- event:
+			continue
+		default:
+		}
+		log.WithFields(log.Fields{"build": bs.BuildID, "subscriber": id}).Warn("dropping a build event for a slow subscriber")
+		if eventType != EventDone {
+			continue
+		}
+		//Only emit sends on the channel, so once an event is taken off, there is room
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- event
+	}
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/whiteblock/genesis/util"
+)
+
+func newTestBuildState(t *testing.T) *BuildState {
+	id, err := util.GetUUIDString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBuildState([]int{}, id)
+}
+
+func TestBuildState_Subscribe(t *testing.T) {
+	bs := newTestBuildState(t)
+	defer os.RemoveAll("/tmp/" + bs.BuildID)
+	events, unsubscribe := bs.Subscribe()
+	defer unsubscribe()
+
+	bs.SetBuildStage("provisioning")
+	bs.emit(EventDone, "")
+
+	expected := []string{EventStage, EventDone}
+	for i, eventType := range expected {
+		event := <-events
+		if event.Type != eventType {
+			t.Errorf("event %d: expected type %s, got %s", i, eventType, event.Type)
+		}
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no more events, got %d", len(events))
+	}
+}
+
+func TestBuildState_Drop(t *testing.T) {
+	bs := newTestBuildState(t)
+	defer os.RemoveAll("/tmp/" + bs.BuildID)
+	events, unsubscribe := bs.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bs.SetBuildStage(fmt.Sprint(i))
+	}
+	if len(events) != subscriberBuffer {
+		t.Fatalf("expected %d queued events, got %d", subscriberBuffer, len(events))
+	}
+	bs.emit(EventDone, "")
+	if len(events) != subscriberBuffer {
+		t.Fatalf("expected %d queued events, got %d", subscriberBuffer, len(events))
+	}
+
+	var last BuildEvent
+	for i := 0; i < subscriberBuffer; i++ {
+		last = <-events
+		if i == 0 && last.Stage != "1" {
+			t.Errorf("expected the oldest event to be dropped, got stage %s first", last.Stage)
+		}
+	}
+	if last.Type != EventDone {
+		t.Errorf("expected the last event to be %s, got %s", EventDone, last.Type)
+	}
+}
+
+func TestBuildState_Unsubscribe(t *testing.T) {
+	bs := newTestBuildState(t)
+	defer os.RemoveAll("/tmp/" + bs.BuildID)
+	events, unsubscribe := bs.Subscribe()
+	other, unsubscribeOther := bs.Subscribe()
+	defer unsubscribeOther()
+
+	unsubscribe()
+	bs.SetBuildStage("provisioning")
+	bs.emit(EventDone, "")
+
+	if len(events) != 0 {
+		t.Errorf("expected no events after unsubscribing, got %d", len(events))
+	}
+	if len(other) != 2 {
+		t.Errorf("expected 2 events for the remaining subscriber, got %d", len(other))
+	}
+}
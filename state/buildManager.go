@@ -30,6 +30,9 @@ var (
 	mux          = sync.RWMutex{}
 )
 
+// ErrBuildInProgress is given when the build lock of a build can't be acquired, as it is still in progress
+var ErrBuildInProgress = fmt.Errorf("there is a build in progress")
+
 /*
    Remove all of the finished build states
 */
@@ -135,6 +138,23 @@ func AcquireBuilding(servers []int, buildID string) error {
 	return nil
 }
 
+// AcquireRebuilding acquires the build lock of an existing build, so that the nodes of its testnet can be
+// modified, and resets its build state. Unlike AcquireBuilding, the build state is kept. Gives
+// ErrBuildInProgress if the build is still in progress.
+func AcquireRebuilding(buildID string) (*BuildState, error) {
+	bs, err := GetBuildStateByID(buildID)
+	if err != nil {
+		return nil, err
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if !bs.Done() {
+		return nil, ErrBuildInProgress
+	}
+	bs.Reset()
+	return bs, nil
+}
+
 // Stop checks if the stop signal has been sent. If this returns true,
 // a building process should return. The ssh client checks this for you.
 // This is fairly naive and will need to be changed for multi-tenancy
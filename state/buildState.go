@@ -58,6 +58,7 @@ type BuildState struct {
 	defers            []func() //Array of functions to run at the end of the build
 	errorCleanupFuncs []func()
 	asyncWaiter       *sync.WaitGroup
+	subscribers       *buildSubscribers
 
 	Servers []int
 	BuildID string
@@ -85,6 +86,7 @@ func NewBuildState(servers []int, buildID string) *BuildState {
 	out.freeze = &sync.RWMutex{}
 	out.mutex = &sync.RWMutex{}
 	out.asyncWaiter = &sync.WaitGroup{}
+	out.subscribers = newBuildSubscribers()
 
 	out.building = 1
 	out.frozen = 0
@@ -131,8 +133,10 @@ func RestoreBuildState(buildID string) (*BuildState, error) {
 	out.freeze = &sync.RWMutex{}
 	out.mutex = &sync.RWMutex{}
 	out.asyncWaiter = &sync.WaitGroup{}
+	out.subscribers = newBuildSubscribers()
 
 	out.Reset()
+	atomic.StoreInt32(&out.building, 0) //nothing can be building a build which had to be restored
 	return out, nil
 }
 
@@ -158,6 +162,7 @@ func (bs *BuildState) Freeze() error {
 	}
 
 	atomic.StoreInt32(&bs.frozen, 1)
+	bs.emit(EventFreeze, "")
 
 	bs.freeze.Lock()
 
@@ -173,6 +178,7 @@ func (bs *BuildState) Unfreeze() error {
 	}
 	bs.freeze.Unlock()
 	atomic.StoreInt32(&bs.frozen, 0)
+	bs.emit(EventThaw, "")
 	return nil
 }
 
@@ -237,6 +243,7 @@ func (bs *BuildState) DoneBuilding() {
 	for _, fn := range bs.defers {
 		go fn() //No need to wait to confirm completion
 	}
+	bs.emit(EventDone, "Finished")
 }
 
 // Done checks if the build is done
@@ -248,8 +255,9 @@ func (bs *BuildState) Done() bool {
 // who query the build status.
 func (bs *BuildState) ReportError(err error) {
 	bs.errMutex.Lock()
-	defer bs.errMutex.Unlock()
 	bs.BuildError = CustomError{What: err.Error(), err: err}
+	bs.errMutex.Unlock()
+	bs.emit(EventError, "")
 
 	_, file, line, ok := runtime.Caller(1)
 	if !ok {
@@ -433,12 +441,14 @@ func (bs *BuildState) SetDeploySteps(steps int) {
 // IncrementDeployProgress increments the deploy process by one step. This is thread safe.
 func (bs *BuildState) IncrementDeployProgress() {
 	atomic.AddUint64(&bs.DeployProgress, 1)
+	bs.emit(EventProgress, "")
 }
 
 // FinishDeploy signals that the deployment process has finished and the
 // blockchain specific process will begin.
 func (bs *BuildState) FinishDeploy() {
 	atomic.StoreUint64(&bs.DeployProgress, atomic.LoadUint64(&bs.DeployTotal))
+	bs.emit(EventProgress, "")
 }
 
 // SetBuildSteps sets the number of steps in the blockchain specific
@@ -451,12 +461,14 @@ func (bs *BuildState) SetBuildSteps(steps int) {
 // IncrementBuildProgress increments the build progress by one step.
 func (bs *BuildState) IncrementBuildProgress() {
 	atomic.AddUint64(&bs.BuildProgress, 1)
+	bs.emit(EventProgress, "")
 }
 
 // FinishMainBuild sets the main build as finished, and signals the start of the
 // side car build
 func (bs *BuildState) FinishMainBuild() {
 	atomic.StoreUint64(&bs.BuildProgress, atomic.LoadUint64(&bs.BuildTotal)-1)
+	bs.emit(EventProgress, "")
 }
 
 // SetSidecarSteps sets the number of steps in the sidecar specific
@@ -474,6 +486,7 @@ func (bs *BuildState) SetSidecars(sidecars int) {
 // IncrementSideCarProgress increments the sidecar build progress by one step.
 func (bs *BuildState) IncrementSideCarProgress() {
 	atomic.AddUint64(&bs.SideCarProgress, 1)
+	bs.emit(EventProgress, "")
 }
 
 // GetProgress gets the progress as a percentage, within the range
@@ -529,7 +542,7 @@ func (bs *BuildState) SetBuildStage(stage string) {
 	bs.mutex.Lock()
 	defer bs.mutex.Unlock()
 	bs.BuildStage = stage
-
+	bs.emit(EventStage, stage)
 }
 
 // Reset sets the build state back the beginning. Used for when
@@ -43,18 +43,46 @@ type Comp struct {
 	RSS float64 `json:"residentSetSize"`
 }
 
+const (
+	// NodeUp is the state of a node whose container is running
+	NodeUp = "up"
+	// NodePaused is the state of a node whose container is paused
+	NodePaused = "paused"
+	// NodeDown is the state of a node whose container is not running
+	NodeDown = "down"
+)
+
 // NodeStatus represents the status of the node
 type NodeStatus struct {
-	Name      string `json:"name"`
-	Server    int    `json:"server"`
-	IP        string `json:"ip"`
-	Up        bool   `json:"up"`
+	Name   string `json:"name"`
+	Server int    `json:"server"`
+	IP     string `json:"ip"`
+	// Up is true only if the node is running, paused nodes are not up
+	Up bool `json:"up"`
+	// State is one of up, paused or down
+	State     string `json:"state"`
 	Resources Comp   `json:"resourceUse"`
 	ID        string `json:"id"`
 	Protocol  string `json:"protocol"`
 	Image     string `json:"image"`
+	// ProcessAlive is true if the main blockchain process of the node is running
+	ProcessAlive bool `json:"processAlive"`
+	// Ready is true if the node passed the readiness probe of its blockchain
+	Ready bool `json:"ready"`
+	// ProbeError is why the node is not ready, as of the last probe
+	ProbeError string `json:"probeError,omitempty"`
+}
+
+// Readiness is the result of probing a node
+type Readiness struct {
+	ProcessAlive bool
+	Ready        bool
+	Err          error
 }
 
+// Prober checks whether the main process of a node is alive, and whether the node is ready
+type Prober func(client ssh.Client, node db.Node) Readiness
+
 // FindNodeIndex finds the index of a node by name and server id
 func FindNodeIndex(status []NodeStatus, name string, serverID int) int {
 	for i, stat := range status {
@@ -108,13 +136,14 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 	serverIDs := db.GetUniqueServerIDs(nodes)
 	out := make([]NodeStatus, len(nodes))
 
-	for _, node := range nodes {
+	for i, node := range nodes {
 		log.WithFields(log.Fields{"node": node.AbsoluteNum, "id": node.ID, "server": node.Server}).Trace("adding node to be check")
-		out[node.AbsoluteNum] = NodeStatus{
+		out[i] = NodeStatus{
 			Name:      fmt.Sprintf("%s%d", conf.NodePrefix, node.LocalID),
 			IP:        node.IP,
 			Server:    node.Server,
 			Up:        false,
+			State:     NodeDown,
 			ID:        node.ID,
 			Protocol:  node.Protocol,
 			Image:     node.Image,
@@ -133,22 +162,30 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		res, err := client.Run(
-			fmt.Sprintf("docker ps | egrep -o '%s[0-9]*' | sort", conf.NodePrefix))
+		res, err := client.Run(fmt.Sprintf("docker ps --format '{{.Names}} {{.Status}}' | egrep '^%s[0-9]+ ' | sort",
+			conf.NodePrefix))
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		names := strings.Split(res, "\n")
-		for _, name := range names {
-			if len(name) == 0 {
+		containers := strings.Split(res, "\n")
+		for _, container := range containers {
+			if len(container) == 0 {
 				continue
 			}
+			name := strings.SplitN(container, " ", 2)[0]
 
 			index := FindNodeIndex(out, name, server.ID)
 			if index == -1 {
 				log.WithFields(log.Fields{"name": name, "server": server.ID}).Warn("unable to find a node")
 				continue
 			}
+			if strings.HasSuffix(container, "(Paused)") {
+				//The processes of a paused container cannot be inspected
+				mux.Lock()
+				out[index].State = NodePaused
+				mux.Unlock()
+				continue
+			}
 			wg.Add(1)
 			go func(client ssh.Client, name string, index int) {
 				defer wg.Done()
@@ -158,6 +195,7 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 				}
 				mux.Lock()
 				out[index].Up = true
+				out[index].State = NodeUp
 				out[index].Resources = resUsage
 				mux.Unlock()
 			}(client, name, index)
@@ -166,3 +204,40 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 	wg.Wait()
 	return out, nil
 }
+
+// CheckNodeHealth checks the status of the nodes like CheckNodeStatus, and then probes each of the nodes
+// which are up for their readiness with the given prober. The statuses are in the same order as the nodes.
+func CheckNodeHealth(nodes []db.Node, probe Prober) ([]NodeStatus, error) {
+	out, err := CheckNodeStatus(nodes)
+	if err != nil {
+		return nil, err
+	}
+	clients := map[int]ssh.Client{}
+	for _, node := range nodes {
+		if _, ok := clients[node.Server]; ok {
+			continue
+		}
+		clients[node.Server], err = GetClient(node.Server)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+	}
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		if !out[i].Up {
+			continue
+		}
+		wg.Add(1)
+		go func(client ssh.Client, node db.Node, i int) {
+			defer wg.Done()
+			res := probe(client, node)
+			out[i].ProcessAlive = res.ProcessAlive
+			out[i].Ready = res.Ready
+			if res.Err != nil {
+				out[i].ProbeError = res.Err.Error()
+			}
+		}(clients[node.Server], node, i)
+	}
+	wg.Wait()
+	return out, nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
 	"sync"
 )
 
@@ -55,9 +56,22 @@ type TestNet struct {
 	CombinedDetails db.DeploymentDetails
 	// LDD is a pointer to latest deployment details
 	LDD *db.DeploymentDetails `json:"-"`
-	mux *sync.RWMutex
+	// Paused contains the absolute numbers of the nodes which are currently paused
+	Paused map[int]bool
+	// DiskFills maps the absolute numbers of the nodes with a filled up disk to the directory which was filled
+	DiskFills map[int]string
+	// Resources maps the absolute numbers of the nodes whose resource limits were changed after they were
+	// built to their new limits
+	Resources map[int]util.Resources
+	mux       *sync.RWMutex
 }
 
+var (
+	// locks serialize the changes to the stored data of each testnet
+	locks    = map[string]*sync.Mutex{}
+	locksMux = sync.Mutex{}
+)
+
 // RestoreTestNet fetches a testnet which already exists.
 func RestoreTestNet(buildID string) (*TestNet, error) {
 	out := new(TestNet)
@@ -134,11 +148,7 @@ func (tn *TestNet) AddNode(node db.Node) *db.Node {
 	tn.mux.Lock()
 	defer tn.mux.Unlock()
 	node.AbsoluteNum = len(tn.Nodes)
-	node.Image = tn.LDD.Images[0]
-	if len(tn.LDD.Images) > node.AbsoluteNum {
-		node.Image = tn.LDD.Images[node.AbsoluteNum]
-		log.WithFields(log.Fields{"image": node.Image, "node": node.AbsoluteNum}).Trace("using given image")
-	}
+	node.Image = tn.LDD.GetImage(node.AbsoluteNum)
 	log.WithFields(log.Fields{"node": node}).Debug("adding a node")
 	tn.NewlyBuiltNodes = append(tn.NewlyBuiltNodes, node)
 	tn.Nodes = append(tn.Nodes, node)
@@ -222,8 +232,123 @@ func (tn *TestNet) AddDetails(dd db.DeploymentDetails) error {
 // stores the current data of tn testnet
 func (tn *TestNet) FinishedBuilding() {
 	tn.BuildState.DoneBuilding()
+	tn.FinishedStep()
+}
+
+// FinishedStep empties the NewlyBuiltNodes and stores the current data of tn testnet, without finishing
+// the build. Used when a build is made up of several steps. The paused nodes, disk fills and resource
+// limits changed while the testnet was being built are kept.
+func (tn *TestNet) FinishedStep() {
 	tn.NewlyBuiltNodes = []db.Node{}
-	tn.Store()
+	err := tn.storeBuild()
+	if err != nil {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "error": err}).Error("failed to store the testnet")
+	}
+}
+
+// storeBuild stores the testnet after it has been built, taking the node states from the stored testnet,
+// as they may have been changed during the build
+func (tn *TestNet) storeBuild() error {
+	unlock := lock(tn.TestNetID)
+	defer unlock()
+	stored := new(TestNet)
+	if db.GetMetaP("testnet_"+tn.TestNetID, stored) == nil {
+		tn.mux.Lock()
+		tn.Paused = stored.Paused
+		tn.DiskFills = stored.DiskFills
+		tn.Resources = stored.Resources
+		tn.mux.Unlock()
+	}
+	tn.mux.RLock()
+	nodes := []int{}
+	for node := range tn.Paused {
+		nodes = append(nodes, node)
+	}
+	for node := range tn.DiskFills {
+		nodes = append(nodes, node)
+	}
+	for node := range tn.Resources {
+		nodes = append(nodes, node)
+	}
+	tn.mux.RUnlock()
+	for _, node := range nodes {
+		if node >= len(tn.Nodes) {
+			tn.ClearNodeState(node)
+		}
+	}
+	return tn.store()
+}
+
+// ClearNodeState forgets whether the given node is paused, has a filled up disk or has changed resource
+// limits. Used when the node is removed, so that a node added in its place starts afresh.
+func (tn *TestNet) ClearNodeState(node int) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	delete(tn.Paused, node)
+	delete(tn.DiskFills, node)
+	delete(tn.Resources, node)
+}
+
+// SetPaused marks the given node as paused or resumed
+func (tn *TestNet) SetPaused(node int, paused bool) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	if tn.Paused == nil {
+		tn.Paused = map[int]bool{}
+	}
+	if paused {
+		tn.Paused[node] = true
+	} else {
+		delete(tn.Paused, node)
+	}
+}
+
+// IsPaused checks if the given node is paused
+func (tn *TestNet) IsPaused(node int) bool {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	return tn.Paused[node]
+}
+
+// SetDiskFill records that the given directory of a node was filled up, an empty dir clears the record
+func (tn *TestNet) SetDiskFill(node int, dir string) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	if tn.DiskFills == nil {
+		tn.DiskFills = map[int]string{}
+	}
+	if len(dir) > 0 {
+		tn.DiskFills[node] = dir
+	} else {
+		delete(tn.DiskFills, node)
+	}
+}
+
+// GetDiskFill gets the directory of a node which was filled up, false if there isn't one
+func (tn *TestNet) GetDiskFill(node int) (string, bool) {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	dir, ok := tn.DiskFills[node]
+	return dir, ok
+}
+
+// SetResources records the new resource limits of a node
+func (tn *TestNet) SetResources(node int, res util.Resources) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	if tn.Resources == nil {
+		tn.Resources = map[int]util.Resources{}
+	}
+	tn.Resources[node] = res
+}
+
+// GetResources gets the resource limits of a node which were changed after it was built, false if
+// they were never changed
+func (tn *TestNet) GetResources(node int) (util.Resources, bool) {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	res, ok := tn.Resources[node]
+	return res, ok
 }
 
 // GetFlatClients takes the clients map and turns it into an array
@@ -299,8 +424,46 @@ func (tn *TestNet) PreOrderNewNodes(sidecar bool) map[int][]ssh.Node {
 }
 
 // Store stores the TestNets data for later retrieval
-func (tn *TestNet) Store() {
-	db.SetMeta("testnet_"+tn.TestNetID, *tn)
+func (tn *TestNet) Store() error {
+	unlock := lock(tn.TestNetID)
+	defer unlock()
+	return tn.store()
+}
+
+func (tn *TestNet) store() error {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	return db.SetMeta("testnet_"+tn.TestNetID, *tn)
+}
+
+// lock locks the stored data of the given testnet, returning the function which unlocks it
+func lock(testnetID string) func() {
+	locksMux.Lock()
+	mux, ok := locks[testnetID]
+	if !ok {
+		mux = &sync.Mutex{}
+		locks[testnetID] = mux
+	}
+	locksMux.Unlock()
+	mux.Lock()
+	return mux.Unlock
+}
+
+// Update restores the testnet, changes it with fn and stores it again. Nothing else can store the
+// testnet in the meantime, so that concurrent changes are not lost. The testnet is not stored if fn
+// gives an error.
+func Update(testnetID string, fn func(tn *TestNet) error) error {
+	unlock := lock(testnetID)
+	defer unlock()
+	tn, err := RestoreTestNet(testnetID)
+	if err != nil {
+		return err
+	}
+	err = fn(tn)
+	if err != nil {
+		return err
+	}
+	return tn.store()
 }
 
 // UpdateAllImages switches all of the nodes to the given docker
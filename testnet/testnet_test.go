@@ -0,0 +1,134 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package testnet
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+)
+
+func newTestTestNet(t *testing.T, nodes int) *TestNet {
+	id, err := util.GetUUIDString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = state.AcquireBuilding([]int{}, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn := &TestNet{
+		TestNetID: id,
+		Nodes:     []db.Node{},
+		Details:   []db.DeploymentDetails{{Nodes: nodes}},
+		mux:       &sync.RWMutex{},
+	}
+	for i := 0; i < nodes; i++ {
+		tn.Nodes = append(tn.Nodes, db.Node{AbsoluteNum: i, TestNetID: id})
+	}
+	if err = tn.Store(); err != nil {
+		t.Fatal(err)
+	}
+	return tn
+}
+
+func TestUpdate(t *testing.T) {
+	tn := newTestTestNet(t, 20)
+	defer tn.Destroy()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(node int) {
+			defer wg.Done()
+			err := Update(tn.TestNetID, func(tn *TestNet) error {
+				tn.SetPaused(node, true)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := Update(tn.TestNetID, func(tn *TestNet) error {
+		tn.SetDiskFill(3, "/data")
+		return fmt.Errorf("failed")
+	})
+	if err == nil {
+		t.Error("expected the error given by the update")
+	}
+
+	restored, err := RestoreTestNet(tn.TestNetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if !restored.IsPaused(i) {
+			t.Errorf("the pausing of node %d was lost", i)
+		}
+	}
+	if _, ok := restored.GetDiskFill(3); ok {
+		t.Error("a failed update was stored")
+	}
+}
+
+func TestFinishedStep(t *testing.T) {
+	tn := newTestTestNet(t, 4)
+	defer tn.Destroy()
+	tn.SetPaused(0, true)
+
+	//Changed while the testnet was being built
+	err := Update(tn.TestNetID, func(tn *TestNet) error {
+		tn.SetPaused(1, true)
+		tn.SetDiskFill(2, "/data")
+		tn.SetResources(3, util.Resources{Cpus: "1"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The build removes the last node
+	tn.ClearNodeState(3)
+	tn.Nodes = tn.Nodes[:3]
+	tn.FinishedStep()
+
+	restored, err := RestoreTestNet(tn.TestNetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.IsPaused(1) || restored.IsPaused(0) {
+		t.Error("expected the stored node states to be kept over the ones of the build")
+	}
+	if dir, ok := restored.GetDiskFill(2); !ok || dir != "/data" {
+		t.Error("the disk fill made during the build was lost")
+	}
+	if _, ok := restored.GetResources(3); ok {
+		t.Error("expected the state of the removed node to be cleared")
+	}
+	if len(restored.Nodes) != 3 {
+		t.Errorf("expected the nodes of the build to be stored, got %d nodes", len(restored.Nodes))
+	}
+}
@@ -52,6 +52,7 @@ type Config struct {
 	MaxNodeMemory           string  `mapstructure:"maxNodeMemory"`
 	MaxNodeCPU              float64 `mapstructure:"maxNodeCpu"`
 	BridgePrefix            string  `mapstructure:"bridgePrefix"`
+	IFBPrefix               string  `mapstructure:"ifbPrefix"`
 	APIEndpoint             string  `mapstructure:"apiEndpoint"`
 	NibblerEndPoint         string  `mapstructure:"nibblerEndPoint"`
 	LogJSON                 bool    `mapstructure:"logJson"`
@@ -73,6 +74,11 @@ type Config struct {
 	EnablePortForwarding    bool    `mapstructure:"enablePortForwarding"`
 	EnableDockerVolumes     bool    `mapstructure:"enableDockerVolumes"`
 	EnableImageBuilding     bool    `mapstructure:"enableImageBuilding"`
+	ArchiveLogsOnDelete     bool    `mapstructure:"archiveLogsOnDelete"`
+	ArchiveLogsOnFailure    bool    `mapstructure:"archiveLogsOnFailure"`
+	FaketimeLibrary         string  `mapstructure:"faketimeLibrary"`
+	DiskDevice              string  `mapstructure:"diskDevice"`
+	InventoryInterval       int     `mapstructure:"inventoryInterval"`
 }
 
 //NodesPerCluster represents the maximum number of nodes allowed in a cluster
@@ -105,6 +111,7 @@ func setViperEnvBindings() {
 	viper.BindEnv("maxNodeMemory", "MAX_NODE_MEMORY")
 	viper.BindEnv("maxNodeCPU", "MAX_NODE_CPU")
 	viper.BindEnv("bridgePrefix", "BRIDGE_PREFIX")
+	viper.BindEnv("ifbPrefix", "IFB_PREFIX")
 	viper.BindEnv("apiEndpoint", "API_ENDPOINT")
 	viper.BindEnv("nibblerEndPoint", "NIBBLER_END_POINT")
 	viper.BindEnv("logJson", "LOG_JSON")
@@ -126,6 +133,11 @@ func setViperEnvBindings() {
 	viper.BindEnv("enablePortForwarding", "ENABLE_PORT_FORWARDING")
 	viper.BindEnv("enableDockerVolumes", "ENABLE_DOCKER_VOLUMES")
 	viper.BindEnv("enableImageBuilding", "ENABLE_IMAGE_BUILDING")
+	viper.BindEnv("archiveLogsOnDelete", "ARCHIVE_LOGS_ON_DELETE")
+	viper.BindEnv("archiveLogsOnFailure", "ARCHIVE_LOGS_ON_FAILURE")
+	viper.BindEnv("faketimeLibrary", "FAKETIME_LIBRARY")
+	viper.BindEnv("diskDevice", "DISK_DEVICE")
+	viper.BindEnv("inventoryInterval", "INVENTORY_INTERVAL")
 }
 func setViperDefaults() {
 	viper.SetDefault("sshUser", os.Getenv("USER"))
@@ -147,6 +159,7 @@ func setViperDefaults() {
 	viper.SetDefault("maxNodeMemory", "")
 	viper.SetDefault("maxNodeCpu", -1)
 	viper.SetDefault("bridgePrefix", "wb_bridge")
+	viper.SetDefault("ifbPrefix", "wb_ifb")
 	viper.SetDefault("apiEndpoint", "https://api.whiteblock.io")
 	viper.SetDefault("nibblerEndPoint", "https://storage.googleapis.com/genesis-public/nibbler/master/bin/linux/amd64/nibbler")
 	viper.SetDefault("logJson", false)
@@ -169,6 +182,11 @@ func setViperDefaults() {
 	viper.SetDefault("enablePortForwarding", true)
 	viper.SetDefault("enableDockerVolumes", true)
 	viper.SetDefault("enableImageBuilding", true)
+	viper.SetDefault("archiveLogsOnDelete", false)
+	viper.SetDefault("archiveLogsOnFailure", false)
+	viper.SetDefault("faketimeLibrary", "/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1")
+	viper.SetDefault("diskDevice", "/dev/sda")
+	viper.SetDefault("inventoryInterval", 600)
 }
 
 // GCPFormatter enables the ability to use genesis logging with Stackdriver
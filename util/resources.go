@@ -40,6 +40,19 @@ type Resources struct {
 	Volumes []string `json:"volumes"`
 	// Ports to be opened for each node, each item associated with one node.
 	Ports []string `json:"ports"`
+	// Device is the block device on the server which the disk limits apply to. Defaults to
+	// diskDevice in the config.
+	Device string `json:"device,omitempty"`
+	// ReadBps limits the rate of reads from Device, in bytes per second. Supports the same
+	// units as Memory.
+	ReadBps string `json:"readBps,omitempty"`
+	// WriteBps limits the rate of writes to Device, in bytes per second. Supports the same
+	// units as Memory.
+	WriteBps string `json:"writeBps,omitempty"`
+	// ReadIOps limits the number of read operations per second on Device
+	ReadIOps string `json:"readIOps,omitempty"`
+	// WriteIOps limits the number of write operations per second on Device
+	WriteIOps string `json:"writeIOps,omitempty"`
 }
 
 func memconv(mem string) (int64, error) {
@@ -66,11 +79,56 @@ func memconv(mem string) (int64, error) {
 	return i * multiplier, nil
 }
 
+// ParseSize converts a size with a unit, such as "512mb", into a number of bytes. If the unit
+// is omitted, then it is assumed to be bytes.
+func ParseSize(size string) (int64, error) {
+	return memconv(size)
+}
+
 // GetMemory gets the memory value as an integer.
 func (res Resources) GetMemory() (int64, error) {
 	return memconv(res.Memory)
 }
 
+// GetDevice gets the block device which the disk limits apply to
+func (res Resources) GetDevice() string {
+	if len(res.Device) == 0 {
+		return conf.DiskDevice
+	}
+	return res.Device
+}
+
+// GetDiskLimits gets the docker run flags for the disk limits, as a map of flag to value
+func (res Resources) GetDiskLimits() (map[string]int64, error) {
+	out := map[string]int64{}
+	for flag, limit := range map[string]string{"device-read-bps": res.ReadBps, "device-write-bps": res.WriteBps} {
+		if len(limit) == 0 {
+			continue
+		}
+		value, err := memconv(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", flag, err.Error())
+		}
+		out[flag] = value
+	}
+	for flag, limit := range map[string]string{"device-read-iops": res.ReadIOps, "device-write-iops": res.WriteIOps} {
+		if len(limit) == 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", flag, err.Error())
+		}
+		out[flag] = value
+	}
+	for flag, value := range out {
+		if value <= 0 {
+			return nil, fmt.Errorf("%s must be greater than zero", flag)
+		}
+	}
+	return out, nil
+}
+
 // Validate ensures that the given resource object is valid, and
 // allowable.
 func (res Resources) Validate() error {
@@ -88,6 +146,17 @@ func (res Resources) Validate() error {
 		return err
 	}
 
+	if !res.NoDiskLimits() {
+		err = ValidateFilePath(res.GetDevice())
+		if err != nil {
+			return err
+		}
+		_, err = res.GetDiskLimits()
+		if err != nil {
+			return err
+		}
+	}
+
 	if !res.NoMemoryLimits() {
 
 		m2, err := res.GetMemory()
@@ -142,7 +211,7 @@ func (res Resources) ValidateAndSetDefaults() error {
 
 // NoLimits checks if the resources object doesn't specify any limits
 func (res Resources) NoLimits() bool {
-	return len(res.Memory) == 0 && len(res.Cpus) == 0
+	return len(res.Memory) == 0 && len(res.Cpus) == 0 && res.NoDiskLimits()
 }
 
 // NoCPULimits checks if the resources object doesn't specify any cpu limits
@@ -154,3 +223,8 @@ func (res Resources) NoCPULimits() bool {
 func (res Resources) NoMemoryLimits() bool {
 	return len(res.Memory) == 0
 }
+
+// NoDiskLimits checks if the resources object doesn't specify any disk limits
+func (res Resources) NoDiskLimits() bool {
+	return len(res.ReadBps) == 0 && len(res.WriteBps) == 0 && len(res.ReadIOps) == 0 && len(res.WriteIOps) == 0
+}
@@ -0,0 +1,52 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResources_GetDiskLimits(t *testing.T) {
+	var test = []struct {
+		res      Resources
+		expected map[string]int64
+		valid    bool
+	}{
+		{res: Resources{}, expected: map[string]int64{}, valid: true},
+		{
+			res:      Resources{ReadBps: "10mb", WriteBps: "512k", WriteIOps: "100"},
+			expected: map[string]int64{"device-read-bps": 10000000, "device-write-bps": 512000, "device-write-iops": 100},
+			valid:    true,
+		},
+		{res: Resources{ReadIOps: "10mb"}, valid: false},
+		{res: Resources{WriteBps: "fast"}, valid: false},
+		{res: Resources{ReadIOps: "0"}, valid: false},
+	}
+	for i, tt := range test {
+		limits, err := tt.res.GetDiskLimits()
+		if (err == nil) != tt.valid {
+			t.Errorf("%d: expected valid to be %v, got error %v", i, tt.valid, err)
+			continue
+		}
+		if tt.valid && !reflect.DeepEqual(limits, tt.expected) {
+			t.Errorf("%d: expected %v, got %v", i, tt.expected, limits)
+		}
+	}
+}
@@ -190,6 +190,11 @@ func CombineConfig(entries []string) string {
 	return out
 }
 
+// ShellQuote quotes str so that it is passed to a command as a single argument
+func ShellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
 /*
    BashExec executes _cmd in bash then return the result
 */